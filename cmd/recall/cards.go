@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var cardsCmd = &cobra.Command{
+	Use:   "cards [topic-title]",
+	Short: "Drill flashcards that are due",
+	Long: `Review the flashcards extracted from your notes by 'recall scan'.
+
+Flashcards are written in the note body as:
+  Q: What is a Pod?
+  A: The smallest deployable unit
+
+  Default Service type :: ClusterIP
+
+  A {{c1::Deployment}} manages {{c2::ReplicaSets}}
+
+Each card's prompt is shown first. Press Enter to reveal the answer, then
+rate your recall from 1-4 (or s to skip, q to quit).
+
+Examples:
+  recall cards                 # All due flashcards
+  recall cards "Kubernetes"    # Due flashcards of one topic
+  recall cards --all "Docker"  # Every flashcard of a topic, due or not`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTopicTitles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		all, _ := cmd.Flags().GetBool("all")
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+		today := clock.EndOfDay(time.Now())
+
+		var cards []storage.Flashcard
+		if len(args) == 1 {
+			topic := store.GetTopicByTitle(args[0])
+			if topic == nil {
+				return fmt.Errorf("topic not found: %s", args[0])
+			}
+			for _, c := range store.GetFlashcards(topic.ID) {
+				if all || !c.Card.Due.After(today) {
+					cards = append(cards, c)
+				}
+			}
+		} else {
+			cards = store.GetDueFlashcards(today)
+		}
+
+		if len(cards) == 0 {
+			fmt.Println("No flashcards due!")
+			return nil
+		}
+
+		in := bufio.NewReader(os.Stdin)
+		ratings := make(map[fsrs.Rating]int)
+		done := 0
+
+		for i := range cards {
+			card := &cards[i]
+			topic := store.GetTopic(card.TopicID)
+			if topic == nil {
+				continue
+			}
+
+			fmt.Printf("\n[%d/%d] %s\n\n", i+1, len(cards), topic.Title)
+			fmt.Printf("Q: %s\n", card.Prompt)
+			shown := time.Now()
+			fmt.Print("\n(press Enter to show the answer) ")
+			if _, err := in.ReadString('\n'); err == io.EOF {
+				break
+			}
+			fmt.Printf("\nA: %s\n\n", card.Answer)
+
+			printRatingOptions()
+			fmt.Print("\nRating [1-4, s=skip, q=quit]: ")
+
+			input, err := in.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			input = strings.ToLower(strings.TrimSpace(input))
+
+			if input == "q" || (err == io.EOF && input == "") {
+				break
+			}
+			if input == "s" {
+				continue
+			}
+			if len(input) != 1 || input[0] < '1' || input[0] > '4' {
+				fmt.Printf("\nInvalid rating: %s, skipping\n", input)
+				continue
+			}
+
+			rating := fsrs.Rating(input[0] - '0')
+			after, err := rateFlashcard(cfg, store, card.ID, topic.Tags, card.Card.LastReview, rating, time.Now(), time.Since(shown))
+			if errors.Is(err, errTopicChanged) {
+				fmt.Printf("\n%v, skipping\n", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			*card = after
+
+			ratings[rating]++
+			done++
+			fmt.Printf("\nNext review: %s\n", card.Card.Due.Format("Jan 2, 2006"))
+		}
+
+		fmt.Printf("\nReviewed %d of %d cards\n", done, len(cards))
+		if done > 0 {
+			fmt.Printf("Again: %d | Hard: %d | Good: %d | Easy: %d\n",
+				ratings[fsrs.Again], ratings[fsrs.Hard], ratings[fsrs.Good], ratings[fsrs.Easy])
+		}
+		return nil
+	},
+}
+
+func init() {
+	cardsCmd.Flags().Bool("all", false, "Include cards that are not due yet (requires a topic)")
+	rootCmd.AddCommand(cardsCmd)
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show or change recall settings",
+	Long: `Read and edit scheduler settings stored in ~/.config/recall/config.json.
+
+Keys:
+  wiki_path                     Wiki directory
+  backend                       Storage backend: json or sqlite (use
+                                'recall migrate' to move existing data)
+  request_retention             Target retention (0.70-0.99, default 0.88)
+  maximum_interval              Maximum days between reviews (default 1825)
+  weights                       Comma-separated FSRS weights (17 values)
+  fuzz                          Randomize intervals by a few percent (true/false)
+  load_balance                  Move intervals towards quieter days (true/false)
+  sync_frontmatter              Write each topic's schedule into its file's
+                                frontmatter (true/false)
+  new_per_day                   Maximum new topics to read per day (0 = no limit)
+  reviews_per_day               Maximum topic reviews per day (0 = no limit)
+  tag_priority                  Comma-separated tags whose new topics come first
+  day_start                     Hour a new day starts at (0-23, default 4)
+  timezone                      Time zone days are counted in, e.g.
+                                Europe/Berlin (default: the system zone)
+  tags.<tag>.request_retention  Retention for topics with <tag>
+  tags.<tag>.maximum_interval   Maximum interval for topics with <tag>
+  tags.<tag>.weights            Weights for topics with <tag>
+
+When a topic has several tags with overrides, the first tag listed in the
+topic's frontmatter wins for each setting.
+
+Examples:
+  recall config get
+  recall config set request_retention 0.9
+  recall config set tags.interview.request_retention 0.95
+  recall config unset tags.trivia`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:         "get [key]",
+	Short:       "Print one or all settings",
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: supportsFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		values := configValues(cfg)
+		if len(args) == 1 && outputFormat(cmd) == formatTable {
+			value, ok := values[args[0]]
+			if !ok {
+				if _, _, err := parseConfigKey(args[0]); err != nil {
+					return err
+				}
+				return fmt.Errorf("%s is not set", args[0])
+			}
+			fmt.Println(value)
+			return nil
+		}
+
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if outputFormat(cmd) != formatTable {
+			var records []configRecord
+			for _, k := range keys {
+				if len(args) == 0 || k == args[0] {
+					records = append(records, configRecord{Key: k, Value: values[k]})
+				}
+			}
+			return printRecords(cmd, []string{"key", "value"}, records)
+		}
+
+		for _, k := range keys {
+			fmt.Printf("%s = %s\n", k, values[k])
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		key, value := args[0], args[1]
+		if key == "wiki_path" {
+			cfg.WikiPath = value
+		} else if key == "backend" {
+			if value != storage.BackendJSON && value != storage.BackendSQLite {
+				return fmt.Errorf("invalid backend: %s (expected json or sqlite)", value)
+			}
+			cfg.Backend = value
+		} else if key == "new_per_day" || key == "reviews_per_day" {
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 0 {
+				return fmt.Errorf("invalid %s: %s", key, value)
+			}
+			if key == "new_per_day" {
+				cfg.NewPerDay = v
+			} else {
+				cfg.ReviewsPerDay = v
+			}
+		} else if key == "day_start" {
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 0 || v > 23 {
+				return fmt.Errorf("invalid day_start: %s (expected an hour from 0 to 23)", value)
+			}
+			cfg.DayStart = &v
+		} else if key == "timezone" {
+			if _, err := time.LoadLocation(value); err != nil || value == "" {
+				return fmt.Errorf("invalid timezone: %s", value)
+			}
+			cfg.Timezone = value
+		} else if key == "tag_priority" {
+			cfg.TagPriority = nil
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					cfg.TagPriority = append(cfg.TagPriority, tag)
+				}
+			}
+		} else if key == "fuzz" || key == "load_balance" || key == "sync_frontmatter" {
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s (expected true or false)", key, value)
+			}
+			switch key {
+			case "fuzz":
+				cfg.Fuzz = v
+			case "load_balance":
+				cfg.LoadBalance = v
+			default:
+				cfg.SyncFrontmatter = v
+			}
+		} else {
+			tag, field, err := parseConfigKey(key)
+			if err != nil {
+				return err
+			}
+
+			settings := cfg.SchedulerSettings
+			if tag != "" {
+				settings = cfg.Tags[tag]
+			}
+			if err := setSchedulerField(&settings, field, value); err != nil {
+				return err
+			}
+			if err := settings.Validate(); err != nil {
+				return err
+			}
+
+			if tag == "" {
+				cfg.SchedulerSettings = settings
+			} else {
+				if cfg.Tags == nil {
+					cfg.Tags = make(map[string]config.SchedulerSettings)
+				}
+				cfg.Tags[tag] = settings
+			}
+		}
+
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s = %s\n", key, value)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Reset a setting to its default",
+	Long: `Reset a setting to its default. Use tags.<tag> to drop all overrides
+for a tag.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		key := args[0]
+		if key == "backend" {
+			cfg.Backend = ""
+		} else if key == "fuzz" {
+			cfg.Fuzz = false
+		} else if key == "load_balance" {
+			cfg.LoadBalance = false
+		} else if key == "sync_frontmatter" {
+			cfg.SyncFrontmatter = false
+		} else if key == "new_per_day" {
+			cfg.NewPerDay = 0
+		} else if key == "reviews_per_day" {
+			cfg.ReviewsPerDay = 0
+		} else if key == "tag_priority" {
+			cfg.TagPriority = nil
+		} else if key == "day_start" {
+			cfg.DayStart = nil
+		} else if key == "timezone" {
+			cfg.Timezone = ""
+		} else if tag, ok := strings.CutPrefix(key, "tags."); ok && !strings.Contains(tag, ".") {
+			delete(cfg.Tags, tag)
+		} else {
+			tag, field, err := parseConfigKey(key)
+			if err != nil {
+				return err
+			}
+
+			settings := cfg.SchedulerSettings
+			if tag != "" {
+				settings = cfg.Tags[tag]
+			}
+			clearSchedulerField(&settings, field)
+
+			if tag == "" {
+				cfg.SchedulerSettings = settings
+			} else if settings.RequestRetention == 0 && settings.MaximumInterval == 0 && len(settings.Weights) == 0 {
+				delete(cfg.Tags, tag)
+			} else {
+				cfg.Tags[tag] = settings
+			}
+		}
+
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("Unset %s\n", key)
+		return nil
+	},
+}
+
+type configRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (r configRecord) values() []string {
+	return []string{r.Key, r.Value}
+}
+
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("wiki path not configured. Run: recall init <path>")
+	}
+	return cfg, nil
+}
+
+// parseConfigKey splits a scheduler key into an optional tag and a field.
+func parseConfigKey(key string) (tag, field string, err error) {
+	field = key
+	if rest, ok := strings.CutPrefix(key, "tags."); ok {
+		i := strings.LastIndex(rest, ".")
+		if i <= 0 {
+			return "", "", fmt.Errorf("invalid key: %s (expected tags.<tag>.<setting>)", key)
+		}
+		tag, field = rest[:i], rest[i+1:]
+	}
+
+	switch field {
+	case "request_retention", "maximum_interval", "weights":
+		return tag, field, nil
+	}
+	return "", "", fmt.Errorf("unknown config key: %s", key)
+}
+
+func setSchedulerField(s *config.SchedulerSettings, field, value string) error {
+	switch field {
+	case "request_retention":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request_retention: %s", value)
+		}
+		s.RequestRetention = v
+	case "maximum_interval":
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid maximum_interval: %s", value)
+		}
+		s.MaximumInterval = v
+	case "weights":
+		var weights []float64
+		for _, part := range strings.Split(value, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return fmt.Errorf("invalid weight: %s", part)
+			}
+			weights = append(weights, v)
+		}
+		s.Weights = weights
+	}
+	return nil
+}
+
+func clearSchedulerField(s *config.SchedulerSettings, field string) {
+	switch field {
+	case "request_retention":
+		s.RequestRetention = 0
+	case "maximum_interval":
+		s.MaximumInterval = 0
+	case "weights":
+		s.Weights = nil
+	}
+}
+
+// configValues flattens the config into key/value strings for display.
+func configValues(cfg *config.Config) map[string]string {
+	values := map[string]string{"wiki_path": cfg.WikiPath}
+	if cfg.Backend != "" {
+		values["backend"] = cfg.Backend
+	}
+	if cfg.Fuzz {
+		values["fuzz"] = "true"
+	}
+	if cfg.LoadBalance {
+		values["load_balance"] = "true"
+	}
+	if cfg.SyncFrontmatter {
+		values["sync_frontmatter"] = "true"
+	}
+	if cfg.NewPerDay > 0 {
+		values["new_per_day"] = strconv.Itoa(cfg.NewPerDay)
+	}
+	if cfg.ReviewsPerDay > 0 {
+		values["reviews_per_day"] = strconv.Itoa(cfg.ReviewsPerDay)
+	}
+	if len(cfg.TagPriority) > 0 {
+		values["tag_priority"] = strings.Join(cfg.TagPriority, ",")
+	}
+	if cfg.DayStart != nil {
+		values["day_start"] = strconv.Itoa(*cfg.DayStart)
+	}
+	if cfg.Timezone != "" {
+		values["timezone"] = cfg.Timezone
+	}
+	addSchedulerValues(values, "", cfg.SchedulerSettings)
+	for tag, s := range cfg.Tags {
+		addSchedulerValues(values, "tags."+tag+".", s)
+	}
+	return values
+}
+
+func addSchedulerValues(values map[string]string, prefix string, s config.SchedulerSettings) {
+	if s.RequestRetention != 0 {
+		values[prefix+"request_retention"] = strconv.FormatFloat(s.RequestRetention, 'g', -1, 64)
+	}
+	if s.MaximumInterval != 0 {
+		values[prefix+"maximum_interval"] = strconv.Itoa(s.MaximumInterval)
+	}
+	if len(s.Weights) > 0 {
+		values[prefix+"weights"] = formatWeights(s.Weights)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd)
+	rootCmd.AddCommand(configCmd)
+}
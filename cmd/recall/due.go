@@ -9,6 +9,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
 
+	"github.com/amiraminb/recall/internal/config"
 	"github.com/amiraminb/recall/internal/fsrs"
 	"github.com/amiraminb/recall/internal/storage"
 	"github.com/spf13/cobra"
@@ -25,49 +26,77 @@ Then lists the topics you should review, with their due status.
 Examples:
   recall due             # Show topics due today
   recall due --week      # Show topics due this week
-  recall due --tag k8s   # Show only k8s-tagged topics due`,
+  recall due --tag k8s   # Show only k8s-tagged topics due
+  recall due --json      # Due topics with their FSRS state, for scripts`,
+	Annotations: supportsFormat,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		store, err := getStorage()
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		tag, _ := cmd.Flags().GetString("tag")
 		week, _ := cmd.Flags().GetBool("week")
 
-		now := time.Now()
-		today := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, now.Location())
-		weekEnd := today.AddDate(0, 0, 7)
-
-		// Summary
-		allTopics := store.GetAllTopics()
-		dueToday := store.GetDueTopics(today)
-		dueWeek := store.GetDueTopics(weekEnd)
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
 
-		fmt.Printf("Topics: %d | Due today: %d | Due this week: %d\n\n",
-			len(allTopics), len(dueToday), len(dueWeek))
+		now := time.Now()
+		today := clock.EndOfDay(now)
+		weekEnd := clock.EndOfDay(clock.AddDays(now, 7))
 
 		// Get topics based on flags
 		until := today
 		if week {
 			until = weekEnd
 		}
-		topics := store.GetDueTopics(until)
+		topics, _ := topicsDue(cfg, store, clock, tag, now, until)
 
-		// Filter by tag if specified
-		if tag != "" {
-			var filtered []storage.Topic
+		if outputFormat(cmd) != formatTable {
+			records := make([]topicRecord, 0, len(topics))
 			for _, t := range topics {
-				for _, tg := range t.Tags {
-					if tg == tag {
-						filtered = append(filtered, t)
-						break
-					}
-				}
+				records = append(records, newTopicRecord(t, clock, now))
 			}
-			topics = filtered
+			return printRecords(cmd, topicHeader, records)
 		}
 
+		// Summary
+		var active, suspended, buried int
+		for _, t := range store.GetAllTopics() {
+			switch {
+			case t.Status == storage.StatusSuspended:
+				suspended++
+			case !t.IsActive():
+				// archived
+			case t.IsBuried(now):
+				buried++
+			default:
+				active++
+			}
+		}
+		dueWeek, allot := topicsDue(cfg, store, clock, "", now, weekEnd)
+
+		fmt.Printf("Topics: %d | Due today: %d | Due this week: %d\n",
+			active, len(allot.Topics), len(dueWeek))
+		if suspended > 0 || buried > 0 {
+			fmt.Printf("Suspended: %d | Buried: %d\n", suspended, buried)
+		}
+		if allot.QueuedNew > 0 || allot.QueuedReviews > 0 {
+			fmt.Printf("Daily limit reached: %d more new and %d more reviews queued\n",
+				allot.QueuedNew, allot.QueuedReviews)
+		}
+		if cards := store.GetDueFlashcards(today); len(cards) > 0 {
+			fmt.Printf("Flashcards due today: %d (run 'recall cards')\n", len(cards))
+		}
+		fmt.Println()
+
 		if len(topics) == 0 {
 			fmt.Println("No topics due for review!")
 			return nil
@@ -85,14 +114,13 @@ Examples:
 		type dueRow struct {
 			title string
 			due   string
-			days  int
 			act   string
 		}
 
 		rows := make([]dueRow, 0, len(topics))
 
 		for _, t := range topics {
-			days := int(time.Until(t.Card.Due).Hours() / 24)
+			days := clock.Days(now, t.DueAt())
 			dueStr := "today"
 			if days < 0 {
 				dueStr = fmt.Sprintf("%d days overdue", -days)
@@ -108,22 +136,10 @@ Examples:
 			rows = append(rows, dueRow{
 				title: t.Title,
 				due:   colorDue(days, dueStr),
-				days:  days,
 				act:   action,
 			})
 		}
 
-		sort.Slice(rows, func(i, j int) bool {
-			ri, rj := statusRank(rows[i].days), statusRank(rows[j].days)
-			if ri != rj {
-				return ri < rj
-			}
-			if rows[i].days != rows[j].days {
-				return rows[i].days < rows[j].days
-			}
-			return rows[i].title < rows[j].title
-		})
-
 		for _, r := range rows {
 			table.Append(truncateText(r.title, maxTitleWidth), r.due, r.act)
 		}
@@ -134,6 +150,22 @@ Examples:
 	},
 }
 
+// sortDueTopics orders topics overdue first, then due today, then future,
+// breaking ties by due day and title.
+func sortDueTopics(topics []storage.Topic, clock fsrs.Clock, now time.Time) {
+	sort.Slice(topics, func(i, j int) bool {
+		di, dj := clock.Days(now, topics[i].DueAt()), clock.Days(now, topics[j].DueAt())
+		ri, rj := statusRank(di), statusRank(dj)
+		if ri != rj {
+			return ri < rj
+		}
+		if di != dj {
+			return di < dj
+		}
+		return topics[i].Title < topics[j].Title
+	})
+}
+
 func colorDue(days int, text string) string {
 	if days < 0 {
 		return color.New(color.FgRed).Sprint(text)
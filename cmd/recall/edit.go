@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -25,36 +26,60 @@ Example:
 		}
 
 		title := args[0]
-		filePath, err := findTopicFile(wikiPath, title)
+		loc, err := findTopic(wikiPath, title)
 		if err != nil {
 			return err
 		}
-		if filePath == "" {
+		if loc == nil {
 			return fmt.Errorf("topic not found: %s", title)
 		}
 
-		if err := openEditor(filePath); err != nil {
+		line := 0
+		if loc.Heading != "" {
+			sec, err := findTopicSection(loc)
+			if err != nil {
+				return err
+			}
+			line = sec.Line
+		}
+
+		if err := openEditor(loc.File, line); err != nil {
 			return err
 		}
 
-		fmt.Printf("Editing %s\n", filePath)
+		fmt.Printf("Editing %s\n", loc.File)
 		return nil
 	},
 }
 
-func openEditor(filePath string) error {
+// openEditor opens filePath in $EDITOR. A positive line jumps to that line
+// in editors that understand the +N argument.
+func openEditor(filePath string, line int) error {
 	editor := strings.TrimSpace(os.Getenv("EDITOR"))
 	if editor == "" {
 		editor = "nvim"
 	}
 
-	cmd := exec.Command(editor, filePath)
+	args := []string{filePath}
+	if line > 0 && lineArgEditors[filepath.Base(editor)] {
+		args = []string{fmt.Sprintf("+%d", line), filePath}
+	}
+
+	cmd := exec.Command(editor, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+var lineArgEditors = map[string]bool{
+	"vi":    true,
+	"vim":   true,
+	"nvim":  true,
+	"nano":  true,
+	"emacs": true,
+}
+
 func init() {
 	rootCmd.AddCommand(editCmd)
 }
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/anki"
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/ics"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export topics and review history for other tools",
+	Long: `Write recall's topics, schedules and review history in formats other
+spaced repetition tools read.`,
+}
+
+var exportAnkiCmd = &cobra.Command{
+	Use:   "anki",
+	Short: "Export topics as an Anki package",
+	Long: `Export topics as an Anki .apkg package, one note per topic.
+
+Each note has a Title field and a Notes field with the topic's note body,
+and carries the topic's tags. Cards keep their recall schedule: due date,
+interval, reps, lapses and FSRS memory state. The review history goes into
+the revlog, so Anki's FSRS can be optimized on it.
+
+Archived topics and flashcards are not exported. Notes are identified by
+topic ID, so importing a newer export into Anki updates the same notes.
+
+Examples:
+  recall export anki                      # Write recall.apkg
+  recall export anki -o kubernetes.apkg --tag kubernetes --deck Kubernetes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		deck, _ := cmd.Flags().GetString("deck")
+		tag, _ := cmd.Flags().GetString("tag")
+
+		wikiPath, err := getWikiPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var topics []storage.Topic
+		for _, t := range filterByTag(store.GetAllTopics(), tag) {
+			if t.Status != storage.StatusArchived {
+				topics = append(topics, t)
+			}
+		}
+		if len(topics) == 0 {
+			fmt.Println("No topics to export.")
+			return nil
+		}
+		sort.Slice(topics, func(i, j int) bool {
+			return topics[i].Created.Before(topics[j].Created)
+		})
+
+		col, missing := ankiCollection(store, clock, wikiPath, topics, deck)
+		if err := anki.Write(output, col); err != nil {
+			return err
+		}
+
+		fmt.Printf("Exported %d topics with %d reviews to %s\n", len(col.Notes), len(col.Reviews), output)
+		printList("Notes not found, exported without a body", missing)
+		return nil
+	},
+}
+
+// ankiCollection converts topics and their history to an Anki collection.
+// It also returns the titles of topics whose notes could not be read.
+func ankiCollection(store storage.Storage, clock fsrs.Clock, wikiPath string, topics []storage.Topic, deck string) (*anki.Collection, []string) {
+	col := &anki.Collection{SchedVer: 2, Deck: deck, DayStart: clock.DayStart}
+
+	history := make(map[string][]storage.ReviewLog)
+	created := time.Now()
+	for _, t := range topics {
+		created = earliest(created, t.Created)
+		for _, log := range store.GetReviewHistory(t.ID) {
+			if log.CardID == "" {
+				history[t.ID] = append(history[t.ID], log)
+				created = earliest(created, log.ReviewedAt)
+			}
+		}
+	}
+	col.Created = clock.StartOfDay(created)
+
+	var missing []string
+	newPosition := 0
+	for _, t := range topics {
+		id := ankiID(t.ID, "")
+
+		body, err := readTopicNotes(&topicLocation{File: filepath.Join(wikiPath, t.File), Heading: t.Heading})
+		if err != nil {
+			missing = append(missing, t.Title)
+		}
+		col.Notes = append(col.Notes, anki.Note{
+			ID:    id,
+			GUID:  t.ID,
+			Model: "recall",
+			Fields: []anki.Field{
+				{Name: "Title", Value: html.EscapeString(t.Title)},
+				{Name: "Notes", Value: noteHTML(body)},
+			},
+			Tags: t.Tags,
+		})
+
+		card := ankiCard(clock, col.Created, t)
+		card.ID, card.NoteID = id, id
+		if card.Type == anki.CardNew {
+			newPosition++
+			card.Due = newPosition
+		}
+		col.Cards = append(col.Cards, card)
+
+		lastInterval := 0
+		for _, log := range history[t.ID] {
+			r := ankiReview(log)
+			r.CardID = id
+			r.LastInterval = lastInterval
+			if log.Kind != storage.KindReschedule {
+				lastInterval = r.Interval
+			}
+			col.Reviews = append(col.Reviews, r)
+		}
+	}
+
+	sort.SliceStable(col.Reviews, func(i, j int) bool {
+		return col.Reviews[i].Time.Before(col.Reviews[j].Time)
+	})
+	return col, missing
+}
+
+// ankiCard mirrors a topic's schedule as Anki card scheduling, with due
+// days counted from created.
+func ankiCard(clock fsrs.Clock, created time.Time, t storage.Topic) anki.Card {
+	c := t.Card
+	card := anki.Card{Reps: c.Reps, Lapses: c.Lapses}
+
+	switch c.State {
+	case fsrs.New:
+		card.Type, card.Queue = anki.CardNew, anki.CardNew
+	case fsrs.Learning, fsrs.Relearn:
+		card.Type, card.Queue = anki.CardLearn, anki.QueueDayLearn
+		if c.State == fsrs.Relearn {
+			card.Type = anki.CardRelearn
+		}
+	default:
+		card.Type, card.Queue = anki.CardReview, anki.CardReview
+	}
+	if card.Type != anki.CardNew {
+		card.Due = clock.Days(created, t.DueAt())
+		card.Interval = max(clock.Days(c.LastReview, c.Due), 1)
+		data, _ := json.Marshal(map[string]float64{
+			"s": math.Round(c.Stability*100) / 100,
+			"d": math.Round(c.Difficulty*1000) / 1000,
+		})
+		card.Data = string(data)
+	}
+	if t.Status == storage.StatusSuspended {
+		card.Queue = anki.QueueSuspended
+	}
+	return card
+}
+
+// ankiReview converts a review log to a revlog entry.
+func ankiReview(log storage.ReviewLog) anki.Review {
+	r := anki.Review{
+		Time:     log.ReviewedAt,
+		Ease:     int(log.Rating),
+		Duration: time.Duration(log.DurationMS) * time.Millisecond,
+		Interval: log.ScheduledDays,
+	}
+	switch {
+	case log.Kind == storage.KindReschedule:
+		r.Type, r.Ease = anki.ReviewManual, 0
+	case log.StateBefore == fsrs.New || log.StateBefore == fsrs.Learning:
+		r.Type = anki.ReviewLearn
+	case log.StateBefore == fsrs.Relearn || log.Kind == storage.KindRelearn:
+		r.Type = anki.ReviewRelearn
+	default:
+		r.Type = anki.ReviewReview
+	}
+	return r
+}
+
+// noteHTML converts a Markdown note body to an Anki field, keeping its
+// line breaks.
+func noteHTML(body string) string {
+	body = html.EscapeString(strings.TrimSpace(body))
+	return strings.ReplaceAll(body, "\n", "<br>")
+}
+
+// ankiID derives a stable numeric ID for a topic or flashcard, below 2^53
+// so tools that parse it as a float keep it exact.
+func ankiID(topicID, cardID string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(topicID + ":" + cardID))
+	return int64(h.Sum64() >> 11)
+}
+
+func earliest(a, b time.Time) time.Time {
+	if !b.IsZero() && b.Before(a) {
+		return b
+	}
+	return a
+}
+
+var exportRevlogCmd = &cobra.Command{
+	Use:   "revlog",
+	Short: "Export review history for the FSRS optimizer",
+	Long: `Export every rating, of topics and flashcards, in the revlog layout read
+by the open-source FSRS optimizer:
+
+  card_id          Numeric ID of the topic or flashcard
+  review_time      Unix time of the review, in milliseconds
+  review_rating    1 (Again) to 4 (Easy)
+  review_state     State before the review: 0 new, 1 learning, 2 review,
+                   3 relearning
+  review_duration  Time to answer in milliseconds, 0 if unknown
+
+Output is CSV unless --format asks for JSON or TSV. Card IDs match the
+note and card IDs of 'recall export anki'. Reschedules are left out, as
+they are not ratings.
+
+Examples:
+  recall export revlog --csv > revlog.csv
+  recall export revlog -o revlog.csv`,
+	Annotations: supportsFormat,
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		format := outputFormat(cmd)
+		if asCSV, _ := cmd.Flags().GetBool("csv"); asCSV || format == formatTable {
+			format = formatCSV
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var records []revlogRecord
+		for _, log := range store.GetAllReviews() {
+			if log.Kind == storage.KindReschedule {
+				continue
+			}
+			records = append(records, revlogRecord{
+				CardID:         ankiID(log.TopicID, log.CardID),
+				ReviewTime:     log.ReviewedAt.UnixMilli(),
+				ReviewRating:   int(log.Rating),
+				ReviewState:    int(log.StateBefore),
+				ReviewDuration: log.DurationMS,
+			})
+		}
+		sort.SliceStable(records, func(i, j int) bool {
+			return records[i].ReviewTime < records[j].ReviewTime
+		})
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := writeRecords(w, format, revlogHeader, records); err != nil {
+			return err
+		}
+		if output != "" {
+			fmt.Printf("Exported %d reviews to %s\n", len(records), output)
+		}
+		return nil
+	},
+}
+
+// revlogRecord is one rating in the FSRS optimizer's revlog layout.
+type revlogRecord struct {
+	CardID         int64 `json:"card_id"`
+	ReviewTime     int64 `json:"review_time"`
+	ReviewRating   int   `json:"review_rating"`
+	ReviewState    int   `json:"review_state"`
+	ReviewDuration int64 `json:"review_duration"`
+}
+
+var revlogHeader = []string{"card_id", "review_time", "review_rating", "review_state", "review_duration"}
+
+func (r revlogRecord) values() []string {
+	return []string{
+		fmt.Sprint(r.CardID), fmt.Sprint(r.ReviewTime), fmt.Sprint(r.ReviewRating),
+		fmt.Sprint(r.ReviewState), fmt.Sprint(r.ReviewDuration),
+	}
+}
+
+var exportICSCmd = &cobra.Command{
+	Use:   "ics",
+	Short: "Export upcoming reviews as an iCalendar file",
+	Long: `Export the topics due in the next days as an iCalendar (.ics) file, with
+one all-day event per topic on its due day. Overdue topics appear today,
+and today's events follow the daily limits, like 'recall due'.
+
+Event UIDs are derived from topic IDs, so importing a newer export updates
+the events in place: a topic that was reviewed moves to its new due day.
+Each event's SEQUENCE is the export time, so calendar apps always take a
+newer export's date over an older one.
+With --digest, each day gets a single event listing its topics instead.
+
+Examples:
+  recall export ics -o reviews.ics
+  recall export ics --days 7 --tag k8s --digest > k8s.ics`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		tag, _ := cmd.Flags().GetString("tag")
+		digest, _ := cmd.Flags().GetBool("digest")
+		output, _ := cmd.Flags().GetString("output")
+		if days < 1 {
+			return fmt.Errorf("invalid days: %d", days)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		now := time.Now()
+		topics, _ := topicsDue(cfg, store, clock, tag, now, clock.EndOfDay(clock.AddDays(now, days-1)))
+
+		cal := &ics.Calendar{Name: "recall"}
+		if tag != "" {
+			cal.Name = "recall: " + tag
+		}
+		if digest {
+			cal.Events = digestEvents(clock, topics, now)
+		} else {
+			cal.Events = topicEvents(clock, topics, now)
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := cal.Write(w, now); err != nil {
+			return err
+		}
+		if output != "" {
+			fmt.Printf("Exported %d events to %s\n", len(cal.Events), output)
+		}
+		return nil
+	},
+}
+
+// eventDay is the calendar date a topic is shown on: its due day, or
+// today when it is overdue.
+func eventDay(clock fsrs.Clock, t storage.Topic, now time.Time) time.Time {
+	due := clock.StartOfDay(t.DueAt())
+	if today := clock.StartOfDay(now); due.Before(today) {
+		due = today
+	}
+	return time.Date(due.Year(), due.Month(), due.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// eventSequence is the SEQUENCE of every exported event: the export time
+// in Unix seconds. Undo and reschedule can move a topic back without a
+// trace in its history, so only the clock is sure to grow between exports.
+func eventSequence(now time.Time) int {
+	return int(now.Unix())
+}
+
+func topicEvents(clock fsrs.Clock, topics []storage.Topic, now time.Time) []ics.Event {
+	events := make([]ics.Event, 0, len(topics))
+	for _, t := range topics {
+		action := "Review"
+		if t.Card.State == fsrs.New {
+			action = "Read"
+		}
+		description := "File: " + t.File
+		if t.Heading != "" {
+			description += "\nSection: " + t.Heading
+		}
+		events = append(events, ics.Event{
+			UID:         t.ID + "@recall",
+			Date:        eventDay(clock, t, now),
+			Summary:     fmt.Sprintf("%s: %s", action, t.Title),
+			Description: description,
+			Categories:  t.Tags,
+			Sequence:    eventSequence(now),
+		})
+	}
+	return events
+}
+
+// digestEvents returns one event per day listing that day's topics. UIDs
+// are derived from the date.
+func digestEvents(clock fsrs.Clock, topics []storage.Topic, now time.Time) []ics.Event {
+	var days []time.Time
+	titles := make(map[time.Time][]string)
+	for _, t := range topics {
+		day := eventDay(clock, t, now)
+		if titles[day] == nil {
+			days = append(days, day)
+		}
+		titles[day] = append(titles[day], "- "+t.Title)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	events := make([]ics.Event, 0, len(days))
+	for _, day := range days {
+		summary := fmt.Sprintf("recall: %d topics", len(titles[day]))
+		if len(titles[day]) == 1 {
+			summary = "recall: 1 topic"
+		}
+		events = append(events, ics.Event{
+			UID:         "recall-" + day.Format("20060102") + "@recall",
+			Date:        day,
+			Summary:     summary,
+			Description: strings.Join(titles[day], "\n"),
+			Sequence:    eventSequence(now),
+		})
+	}
+	return events
+}
+
+func init() {
+	exportAnkiCmd.Flags().StringP("output", "o", "recall.apkg", "Package file to write")
+	exportAnkiCmd.Flags().String("deck", "recall", "Name of the Anki deck")
+	exportAnkiCmd.Flags().String("tag", "", "Only export topics with this tag")
+	exportRevlogCmd.Flags().StringP("output", "o", "", "File to write instead of stdout")
+	exportRevlogCmd.Flags().Bool("csv", false, "Write CSV (the default)")
+	exportICSCmd.Flags().Int("days", 30, "Number of days to export, starting today")
+	exportICSCmd.Flags().String("tag", "", "Only export topics with this tag")
+	exportICSCmd.Flags().Bool("digest", false, "One event per day instead of one per topic")
+	exportICSCmd.Flags().StringP("output", "o", "", "File to write instead of stdout")
+	exportCmd.AddCommand(exportAnkiCmd, exportRevlogCmd, exportICSCmd)
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+var forecastCmd = &cobra.Command{
+	Use:   "forecast",
+	Short: "Project how many reviews are due in the coming days",
+	Long: `Show how many topic and flashcard reviews fall on each upcoming day,
+based on their current due dates. Overdue reviews count towards today.
+Topics you have not read yet are not included.
+
+With --simulate, each review is played forward: a card is recalled with
+probability equal to its target retention (rated Good) and forgotten
+otherwise (rated Again), and its next review is scheduled by FSRS. This
+shows the follow-up reviews that today's due dates alone miss. The
+simulation uses a fixed seed, so repeated runs give the same result.
+
+Examples:
+  recall forecast                       # Next 30 days
+  recall forecast --days 90 --weekly    # Next 90 days, one row per week
+  recall forecast --simulate --json     # Simulated daily counts for scripts`,
+	Annotations: supportsFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 1 {
+			return fmt.Errorf("invalid days: %d", days)
+		}
+		simulate, _ := cmd.Flags().GetBool("simulate")
+		weekly, _ := cmd.Flags().GetBool("weekly")
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		today := clock.StartOfDay(now)
+		end := clock.AddDays(now, days)
+		rng := rand.New(rand.NewPCG(1, 1))
+
+		topics := make([]int, days)
+		cards := make([]int, days)
+		unread := 0
+
+		// project counts a card's reviews until the end of the forecast,
+		// following up with simulated reviews if asked.
+		project := func(counts []int, card fsrs.Card, due time.Time, tags []string) error {
+			var scheduler *fsrs.FSRS
+			if simulate {
+				s, err := schedulerFor(cfg, tags)
+				if err != nil {
+					return err
+				}
+				scheduler = s
+			}
+
+			for due.Before(end) {
+				counts[max(0, clock.Days(today, due))]++
+				if !simulate {
+					return nil
+				}
+
+				at := due
+				if at.Before(now) {
+					at = now
+				}
+				rating := fsrs.Again
+				if rng.Float64() < scheduler.Params.RequestRetention {
+					rating = fsrs.Good
+				}
+				card = scheduler.Review(card, rating, at)
+				due = card.Due
+			}
+			return nil
+		}
+
+		for _, t := range store.GetAllTopics() {
+			if !t.IsActive() {
+				continue
+			}
+			if t.Card.State == fsrs.New {
+				unread++
+				continue
+			}
+			if err := project(topics, t.Card, t.DueAt(), t.Tags); err != nil {
+				return err
+			}
+
+			for _, c := range store.GetFlashcards(t.ID) {
+				due := c.Card.Due
+				if t.BuriedUntil.After(due) {
+					due = t.BuriedUntil
+				}
+				if err := project(cards, c.Card, due, t.Tags); err != nil {
+					return err
+				}
+			}
+		}
+
+		rows := forecastRows(clock, today, topics, cards, weekly)
+
+		if outputFormat(cmd) != formatTable {
+			return printRecords(cmd, forecastHeader, rows)
+		}
+
+		peak := rows[0]
+		total := 0
+		for _, r := range rows {
+			if r.Topics+r.Flashcards > peak.Topics+peak.Flashcards {
+				peak = r
+			}
+			total += r.Topics + r.Flashcards
+		}
+
+		daily := make([]int, days)
+		for i := range daily {
+			daily[i] = topics[i] + cards[i]
+		}
+
+		fmt.Printf("Next %d days: %d reviews | Peak: %s (%d)", days, total,
+			peak.Date.Format("Jan 2"), peak.Topics+peak.Flashcards)
+		if unread > 0 {
+			fmt.Printf(" | Unread topics: %d", unread)
+		}
+		fmt.Println()
+		fmt.Printf("  %s\n\n", sparkline(daily))
+
+		label := "Day"
+		if weekly {
+			label = "Week of"
+		}
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header(label, "Topics", "Flashcards", "")
+		for _, r := range rows {
+			fraction := 0.0
+			if n := peak.Topics + peak.Flashcards; n > 0 {
+				fraction = float64(r.Topics+r.Flashcards) / float64(n)
+			}
+			table.Append(r.Date.Format("Mon Jan 2"), fmt.Sprint(r.Topics), fmt.Sprint(r.Flashcards),
+				bar(fraction, barWidth))
+		}
+		table.Render()
+
+		if simulate {
+			fmt.Println("\nIncludes simulated follow-up reviews.")
+		}
+		return nil
+	},
+}
+
+// forecastRecord is the schema for one day (or week) of the forecast.
+type forecastRecord struct {
+	Date       time.Time `json:"date"`
+	Topics     int       `json:"topics"`
+	Flashcards int       `json:"flashcards"`
+}
+
+var forecastHeader = []string{"date", "topics", "flashcards"}
+
+func (r forecastRecord) values() []string {
+	return []string{r.Date.Format(time.DateOnly), fmt.Sprint(r.Topics), fmt.Sprint(r.Flashcards)}
+}
+
+// forecastRows turns daily counts into one row per day, or per 7 days
+// starting today.
+func forecastRows(clock fsrs.Clock, today time.Time, topics, cards []int, weekly bool) []forecastRecord {
+	step := 1
+	if weekly {
+		step = 7
+	}
+
+	var rows []forecastRecord
+	for i := range topics {
+		if i%step == 0 {
+			rows = append(rows, forecastRecord{Date: clock.AddDays(today, i)})
+		}
+		rows[len(rows)-1].Topics += topics[i]
+		rows[len(rows)-1].Flashcards += cards[i]
+	}
+	return rows
+}
+
+func init() {
+	forecastCmd.Flags().Int("days", 30, "Number of days to forecast")
+	forecastCmd.Flags().Bool("simulate", false, "Simulate future reviews at the target retention")
+	forecastCmd.Flags().Bool("weekly", false, "Show one row per week instead of per day")
+	rootCmd.AddCommand(forecastCmd)
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+// Output formats for --format.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatTSV   = "tsv"
+)
+
+// formatAnnotation marks commands that support machine-readable output.
+// Others reject any --format but table, so scripts never parse prose.
+const formatAnnotation = "recall.format"
+
+var supportsFormat = map[string]string{formatAnnotation: "true"}
+
+// checkFormat validates --format (and its --json shorthand) before any
+// command runs.
+func checkFormat(cmd *cobra.Command, args []string) error {
+	format := outputFormat(cmd)
+	switch format {
+	case formatTable:
+		// fatih/color already disables itself when stdout is not a
+		// terminal or NO_COLOR is set.
+		return nil
+	case formatJSON, formatCSV, formatTSV:
+	default:
+		return fmt.Errorf("invalid format: %s (expected table, json, csv or tsv)", format)
+	}
+
+	if cmd.Annotations[formatAnnotation] == "" {
+		return fmt.Errorf("%s does not support --format %s", cmd.CommandPath(), format)
+	}
+	color.NoColor = true
+	return nil
+}
+
+func outputFormat(cmd *cobra.Command) string {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return formatJSON
+	}
+	format, _ := cmd.Flags().GetString("format")
+	return format
+}
+
+// record is one row of machine-readable output. Its JSON field names and
+// its values() order must match the header passed to writeRecords.
+type record interface {
+	values() []string
+}
+
+// writeRecords prints records as a JSON array, or as CSV/TSV with a header
+// line. Empty results are still valid documents ([] or a bare header).
+func writeRecords[T record](w io.Writer, format string, header []string, records []T) error {
+	switch format {
+	case formatJSON:
+		if records == nil {
+			records = []T{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		for _, r := range records {
+			cw.Write(r.values())
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case formatTSV:
+		var b strings.Builder
+		writeTSVLine(&b, header)
+		for _, r := range records {
+			writeTSVLine(&b, r.values())
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	return fmt.Errorf("invalid format: %s", format)
+}
+
+// TSV has no quoting, so tabs and line breaks inside fields become spaces.
+var tsvEscaper = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+
+func writeTSVLine(b *strings.Builder, fields []string) {
+	for i, f := range fields {
+		if i > 0 {
+			b.WriteByte('\t')
+		}
+		b.WriteString(tsvEscaper.Replace(f))
+	}
+	b.WriteByte('\n')
+}
+
+func printRecords[T record](cmd *cobra.Command, header []string, records []T) error {
+	return writeRecords(os.Stdout, outputFormat(cmd), header, records)
+}
+
+// topicRecord is the schema for topics in due and history output.
+type topicRecord struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	File           string     `json:"file"`
+	Heading        string     `json:"heading"`
+	Tags           []string   `json:"tags"`
+	Status         string     `json:"status"`
+	Due            time.Time  `json:"due"`
+	State          string     `json:"state"`
+	Stability      float64    `json:"stability"`
+	Difficulty     float64    `json:"difficulty"`
+	Retrievability *float64   `json:"retrievability"` // null until first read
+	Reps           int        `json:"reps"`
+	Lapses         int        `json:"lapses"`
+	LastReview     *time.Time `json:"last_review"`
+}
+
+var topicHeader = []string{
+	"id", "title", "file", "heading", "tags", "status", "due", "state",
+	"stability", "difficulty", "retrievability", "reps", "lapses", "last_review",
+}
+
+func newTopicRecord(t storage.Topic, clock fsrs.Clock, now time.Time) topicRecord {
+	r := topicRecord{
+		ID:         t.ID,
+		Title:      t.Title,
+		File:       t.File,
+		Heading:    t.Heading,
+		Tags:       t.Tags,
+		Status:     topicStatus(t, now),
+		Due:        t.DueAt(),
+		State:      stateName(t.Card.State),
+		Stability:  t.Card.Stability,
+		Difficulty: t.Card.Difficulty,
+		Reps:       t.Card.Reps,
+		Lapses:     t.Card.Lapses,
+	}
+	if r.Tags == nil {
+		r.Tags = []string{}
+	}
+	if t.Card.State != fsrs.New {
+		retrievability := fsrs.Retrievability(t.Card, now, clock)
+		r.Retrievability = &retrievability
+		lastReview := t.Card.LastReview
+		r.LastReview = &lastReview
+	}
+	return r
+}
+
+func (r topicRecord) values() []string {
+	var retrievability, lastReview string
+	if r.Retrievability != nil {
+		retrievability = formatFloat(*r.Retrievability)
+	}
+	if r.LastReview != nil {
+		lastReview = r.LastReview.Format(time.RFC3339)
+	}
+	return []string{
+		r.ID, r.Title, r.File, r.Heading, strings.Join(r.Tags, ","), r.Status,
+		r.Due.Format(time.RFC3339), r.State, formatFloat(r.Stability),
+		formatFloat(r.Difficulty), retrievability, fmt.Sprint(r.Reps),
+		fmt.Sprint(r.Lapses), lastReview,
+	}
+}
+
+// topicStatus is the topic's status for output: active, buried, suspended
+// or archived.
+func topicStatus(t storage.Topic, now time.Time) string {
+	if t.IsActive() {
+		if t.IsBuried(now) {
+			return "buried"
+		}
+		return "active"
+	}
+	return t.Status
+}
+
+func stateName(s fsrs.State) string {
+	switch s {
+	case fsrs.New:
+		return "new"
+	case fsrs.Learning:
+		return "learning"
+	case fsrs.Review:
+		return "review"
+	case fsrs.Relearn:
+		return "relearning"
+	}
+	return fmt.Sprint(int(s))
+}
+
+func formatFloat(v float64) string {
+	return fmt.Sprintf("%.4f", v)
+}
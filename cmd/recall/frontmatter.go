@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/parser"
+	"github.com/amiraminb/recall/internal/storage"
+)
+
+// syncFrontmatter writes the schedule of whole-file topics into their
+// files' frontmatter when sync_frontmatter is on. Section topics share
+// their file's frontmatter and are left out.
+func syncFrontmatter(topics ...storage.Topic) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if cfg == nil || !cfg.SyncFrontmatter {
+		return nil
+	}
+	clock, err := cfg.Clock()
+	if err != nil {
+		return err
+	}
+
+	for _, t := range topics {
+		if t.Heading != "" || t.Status == storage.StatusArchived {
+			continue
+		}
+		err := parser.SetFrontmatterFields(filepath.Join(cfg.WikiPath, t.File), scheduleFields(t.Card, clock))
+		if os.IsNotExist(err) {
+			continue // Orphaned; 'recall scan' reports it
+		}
+		if err != nil {
+			return fmt.Errorf("syncing frontmatter of %s: %w", t.File, err)
+		}
+	}
+	return nil
+}
+
+// scheduleFields are the frontmatter fields describing a card. New cards
+// have no due date or last review.
+func scheduleFields(card fsrs.Card, clock fsrs.Clock) []parser.Field {
+	var due, lastReview any
+	if card.State != fsrs.New {
+		due = clock.Date(card.Due)
+		lastReview = clock.In(card.LastReview).Format(time.RFC3339)
+	}
+	return []parser.Field{
+		{Key: "recall_due", Value: due},
+		{Key: "recall_state", Value: stateName(card.State)},
+		{Key: "recall_stability", Value: math.Round(card.Stability*100) / 100},
+		{Key: "recall_difficulty", Value: math.Round(card.Difficulty*100) / 100},
+		{Key: "recall_last_review", Value: lastReview},
+	}
+}
+
+// cardFromFrontmatter rebuilds a card from the fields written by
+// syncFrontmatter. It reports false when the file has none or the card
+// is new.
+func cardFromFrontmatter(fm *parser.Frontmatter, clock fsrs.Clock) (fsrs.Card, bool, error) {
+	card := fsrs.NewCard()
+	switch strings.TrimSpace(fm.RecallState) {
+	case "", "new":
+		return card, false, nil
+	case "learning":
+		card.State = fsrs.Learning
+	case "review":
+		card.State = fsrs.Review
+	case "relearning":
+		card.State = fsrs.Relearn
+	default:
+		return card, false, fmt.Errorf("invalid recall_state: %s", fm.RecallState)
+	}
+
+	var err error
+	card.Due, err = parseDay(clock, time.DateOnly, fm.RecallDue)
+	if err != nil {
+		return card, false, fmt.Errorf("invalid recall_due: %s", fm.RecallDue)
+	}
+	card.LastReview, err = time.Parse(time.RFC3339, strings.TrimSpace(fm.RecallLastReview))
+	if err != nil {
+		return card, false, fmt.Errorf("invalid recall_last_review: %s", fm.RecallLastReview)
+	}
+	card.Stability, err = strconv.ParseFloat(strings.TrimSpace(fm.RecallStability), 64)
+	if err != nil || card.Stability <= 0 {
+		return card, false, fmt.Errorf("invalid recall_stability: %s", fm.RecallStability)
+	}
+	card.Difficulty, err = strconv.ParseFloat(strings.TrimSpace(fm.RecallDifficulty), 64)
+	if err != nil || card.Difficulty < 1 || card.Difficulty > 10 {
+		return card, false, fmt.Errorf("invalid recall_difficulty: %s", fm.RecallDifficulty)
+	}
+
+	// The review count is not synced; the card was reviewed at least once.
+	card.Reps = 1
+	return card, true, nil
+}
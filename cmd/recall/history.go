@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/amiraminb/recall/internal/config"
 	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
@@ -20,7 +23,9 @@ With a topic title, shows the complete review history for that topic.
 
 Examples:
   recall history                     # List all topics with status
-  recall history "Docker Networking" # Show full history for topic`,
+  recall history "Docker Networking" # Show full history for topic
+  recall history --format csv        # All topics with FSRS state as CSV`,
+	Annotations:       supportsFormat,
 	Args:              cobra.MaximumNArgs(1),
 	ValidArgsFunction: completeTopicTitles,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -28,10 +33,28 @@ Examples:
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		// No args: show all topics with status
 		if len(args) == 0 {
 			topics := store.GetAllTopics()
+			if outputFormat(cmd) != formatTable {
+				cfg, err := config.Load()
+				if err != nil {
+					return err
+				}
+				clock, err := cfg.Clock()
+				if err != nil {
+					return err
+				}
+
+				now := time.Now()
+				records := make([]topicRecord, 0, len(topics))
+				for _, t := range topics {
+					records = append(records, newTopicRecord(t, clock, now))
+				}
+				return printRecords(cmd, topicHeader, records)
+			}
 			if len(topics) == 0 {
 				fmt.Println("No topics tracked yet.")
 				return nil
@@ -68,6 +91,13 @@ Examples:
 		}
 
 		history := store.GetReviewHistory(topic.ID)
+		if outputFormat(cmd) != formatTable {
+			records := make([]reviewRecord, 0, len(history))
+			for _, r := range history {
+				records = append(records, newReviewRecord(topic, r))
+			}
+			return printRecords(cmd, reviewHeader, records)
+		}
 		if len(history) == 0 {
 			fmt.Printf("No history for: %s\n", title)
 			return nil
@@ -76,27 +106,24 @@ Examples:
 		fmt.Printf("History for: %s\n\n", title)
 
 		table := tablewriter.NewTable(os.Stdout)
-		table.Header("Date", "Type", "Rating")
+		table.Header("Date", "Type", "Rating", "Recall", "Interval")
 
 		var rows [][]any
-		for i, r := range history {
+		for _, r := range history {
 			date := r.ReviewedAt.Format("Jan 2, 2006")
-			if i == 0 {
-				understandingNames := map[fsrs.Rating]string{
-					1: "didn't understand",
-					2: "partially understood",
-					3: "understood well",
-					4: "mastered",
+			interval := fmt.Sprintf("%dd", r.ScheduledDays)
+			switch r.Kind {
+			case storage.KindRead:
+				rows = append(rows, []any{date, "First read", understandingNames[r.Rating], "-", interval})
+			case storage.KindReschedule:
+				rows = append(rows, []any{date, "Reschedule", "-", "-", interval})
+			default:
+				kind := "Review"
+				if r.Kind == storage.KindRelearn {
+					kind = "Relearn"
 				}
-				rows = append(rows, []any{date, "First read", understandingNames[r.Rating]})
-			} else {
-				ratingNames := map[fsrs.Rating]string{
-					fsrs.Again: "Again",
-					fsrs.Hard:  "Hard",
-					fsrs.Good:  "Good",
-					fsrs.Easy:  "Easy",
-				}
-				rows = append(rows, []any{date, "Review", ratingNames[r.Rating]})
+				recall := fmt.Sprintf("%.0f%%", r.Retrievability*100)
+				rows = append(rows, []any{date, kind, ratingName(r.Rating), recall, interval})
 			}
 		}
 
@@ -106,6 +133,60 @@ Examples:
 	},
 }
 
+// reviewRecord is the schema for one entry of a topic's history.
+type reviewRecord struct {
+	TopicID        string      `json:"topic_id"`
+	Title          string      `json:"title"`
+	ReviewedAt     time.Time   `json:"reviewed_at"`
+	Timezone       string      `json:"timezone"`
+	Type           string      `json:"type"` // read, review, relearn or reschedule
+	Rating         fsrs.Rating `json:"rating"`
+	StateBefore    string      `json:"state_before"`
+	StateAfter     string      `json:"state_after"`
+	ElapsedDays    float64     `json:"elapsed_days"`
+	ScheduledDays  int         `json:"scheduled_days"`
+	Retrievability float64     `json:"retrievability"`
+	DurationMS     int64       `json:"duration_ms"`
+}
+
+var reviewHeader = []string{
+	"topic_id", "title", "reviewed_at", "timezone", "type", "rating", "state_before", "state_after",
+	"elapsed_days", "scheduled_days", "retrievability", "duration_ms",
+}
+
+// understandingNames describe the ratings of a first read.
+var understandingNames = map[fsrs.Rating]string{
+	1: "didn't understand",
+	2: "partially understood",
+	3: "understood well",
+	4: "mastered",
+}
+
+func newReviewRecord(topic *storage.Topic, r storage.ReviewLog) reviewRecord {
+	return reviewRecord{
+		TopicID:        topic.ID,
+		Title:          topic.Title,
+		ReviewedAt:     r.ReviewedAt,
+		Timezone:       r.Timezone,
+		Type:           r.Kind,
+		Rating:         r.Rating,
+		StateBefore:    stateName(r.StateBefore),
+		StateAfter:     stateName(r.StateAfter),
+		ElapsedDays:    r.ElapsedDays,
+		ScheduledDays:  r.ScheduledDays,
+		Retrievability: r.Retrievability,
+		DurationMS:     r.DurationMS,
+	}
+}
+
+func (r reviewRecord) values() []string {
+	return []string{
+		r.TopicID, r.Title, r.ReviewedAt.Format(time.RFC3339), r.Timezone, r.Type, fmt.Sprint(int(r.Rating)),
+		r.StateBefore, r.StateAfter, formatFloat(r.ElapsedDays), fmt.Sprint(r.ScheduledDays),
+		formatFloat(r.Retrievability), fmt.Sprint(r.DurationMS),
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(historyCmd)
 }
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/amiraminb/recall/internal/anki"
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/parser"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+// maxUnmatchedListed caps the unmatched items an import report lists.
+const maxUnmatchedListed = 20
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import review history from other tools",
+	Long: `Bring review history and schedules from other spaced repetition tools into
+recall, for topics that are already tracked. Run 'recall scan' first.`,
+}
+
+var importAnkiCmd = &cobra.Command{
+	Use:   "anki <file>",
+	Short: "Import review history from an Anki package or collection",
+	Long: `Import review history from an Anki .apkg package or collection file.
+
+Each Anki note is matched to a topic by title: by default the note's first
+field, or the field named with --field. With --tag-prefix, a note tag such
+as topic::Docker_Networking names the topic instead (underscores read as
+spaces). Matching ignores case and HTML formatting.
+
+The revlog of the matching card is replayed through the current scheduler
+to rebuild the topic's schedule and history. When several cards match the
+same topic, the one with the most reviews is used. Topics that already
+have recall history are skipped.
+
+Packages from Anki 2.1.50 and later must be exported with "Support older
+Anki versions" enabled.
+
+Examples:
+  recall import anki deck.apkg --dry-run     # Report what would match
+  recall import anki deck.apkg --field Topic
+  recall import anki collection.anki2 --tag-prefix topic::`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		field, _ := cmd.Flags().GetString("field")
+		tagPrefix, _ := cmd.Flags().GetString("tag-prefix")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if field != "" && tagPrefix != "" {
+			return fmt.Errorf("--field and --tag-prefix cannot be combined")
+		}
+
+		col, err := anki.Open(args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var plan *ankiPlan
+		if dryRun {
+			plan, err = planAnkiImport(cfg, store, col, field, tagPrefix)
+		} else {
+			err = store.Update(func() error {
+				plan, err = planAnkiImport(cfg, store, col, field, tagPrefix)
+				if err != nil {
+					return err
+				}
+				return applyImport(store, plan.Matches)
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		plan.print(clock)
+		if dryRun {
+			fmt.Println("Dry run, nothing imported.")
+			return nil
+		}
+		if err := syncFrontmatter(importedTopics(plan.Matches)...); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d reviews for %d topics.\n", plan.reviews(), len(plan.Matches))
+		return nil
+	},
+}
+
+// importMatch is a topic with the review history to import for it.
+type importMatch struct {
+	Topic storage.Topic
+	Logs  []storage.ReviewLog
+	Card  fsrs.Card // The schedule after replaying Logs
+}
+
+// ankiPlan is what an Anki import would do.
+type ankiPlan struct {
+	Notes     int
+	Matches   []importMatch
+	Unmatched []string // Notes without a topic
+	Skipped   []string // Topics that already have history
+	Unrated   []string // Topics whose notes have no reviews
+}
+
+// planAnkiImport matches Anki notes to topics and replays the revlog of
+// each topic's best card.
+func planAnkiImport(cfg *config.Config, store storage.Storage, col *anki.Collection, field, tagPrefix string) (*ankiPlan, error) {
+	topics := make(map[string]storage.Topic)
+	for _, t := range store.GetAllTopics() {
+		if t.Status != storage.StatusArchived {
+			topics[strings.ToLower(t.Title)] = t
+		}
+	}
+
+	ratings := make(map[int64][]anki.Review)
+	for _, r := range col.Reviews {
+		if _, ok := col.Rating(r); ok {
+			ratings[r.CardID] = append(ratings[r.CardID], r)
+		}
+	}
+	cardsByNote := make(map[int64][]anki.Card)
+	for _, c := range col.Cards {
+		cardsByNote[c.NoteID] = append(cardsByNote[c.NoteID], c)
+	}
+
+	plan := &ankiPlan{Notes: len(col.Notes)}
+	best := make(map[string]int64) // Topic ID to card
+	var order []storage.Topic
+	for _, n := range col.Notes {
+		titles := ankiNoteTitles(n, field, tagPrefix)
+		matched := false
+		for _, title := range titles {
+			t, ok := topics[strings.ToLower(title)]
+			if !ok {
+				continue
+			}
+			matched = true
+			for _, c := range cardsByNote[n.ID] {
+				cur, seen := best[t.ID]
+				if !seen {
+					order = append(order, t)
+				}
+				if !seen || len(ratings[c.ID]) > len(ratings[cur]) {
+					best[t.ID] = c.ID
+				}
+			}
+		}
+		if !matched {
+			name := strings.Join(titles, ", ")
+			if name == "" {
+				name = fmt.Sprintf("note %d", n.ID)
+			}
+			plan.Unmatched = append(plan.Unmatched, name)
+		}
+	}
+
+	for _, t := range order {
+		history := ratings[best[t.ID]]
+		if len(history) == 0 {
+			plan.Unrated = append(plan.Unrated, t.Title)
+			continue
+		}
+		if len(store.GetReviewHistory(t.ID)) > 0 {
+			plan.Skipped = append(plan.Skipped, t.Title)
+			continue
+		}
+
+		scheduler, err := schedulerFor(cfg, t.Tags)
+		if err != nil {
+			return nil, err
+		}
+		m := importMatch{Topic: t, Card: fsrs.NewCard()}
+		for _, r := range history {
+			rating, _ := col.Rating(r)
+			after := scheduler.Review(m.Card, rating, r.Time)
+			m.Logs = append(m.Logs, storage.NewReviewLog(scheduler.Clock, t.ID, "", rating, m.Card, after, r.Duration))
+			m.Card = after
+		}
+		plan.Matches = append(plan.Matches, m)
+	}
+	return plan, nil
+}
+
+// ankiNoteTitles returns the topic titles a note may refer to.
+func ankiNoteTitles(n anki.Note, field, tagPrefix string) []string {
+	if tagPrefix != "" {
+		var titles []string
+		for _, tag := range n.Tags {
+			if len(tag) > len(tagPrefix) && strings.EqualFold(tag[:len(tagPrefix)], tagPrefix) {
+				titles = append(titles, strings.ReplaceAll(tag[len(tagPrefix):], "_", " "))
+			}
+		}
+		return titles
+	}
+
+	var value string
+	if field == "" {
+		if len(n.Fields) > 0 {
+			value = n.Fields[0].Value
+		}
+	} else {
+		value, _ = n.Field(field)
+	}
+	if title := anki.PlainText(value); title != "" {
+		return []string{title}
+	}
+	return nil
+}
+
+// applyImport stores imported schedules and history. Call it inside
+// store.Update.
+func applyImport(store storage.Storage, matches []importMatch) error {
+	for _, m := range matches {
+		topic := store.GetTopic(m.Topic.ID)
+		if topic == nil {
+			return fmt.Errorf("topic not found: %s", m.Topic.ID)
+		}
+		topic.Card = m.Card
+		if err := store.UpdateTopic(topic); err != nil {
+			return err
+		}
+		for _, log := range m.Logs {
+			if err := store.AddReview(log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importedTopics returns the matched topics with their imported cards.
+func importedTopics(matches []importMatch) []storage.Topic {
+	topics := make([]storage.Topic, len(matches))
+	for i, m := range matches {
+		topics[i] = m.Topic
+		topics[i].Card = m.Card
+	}
+	return topics
+}
+
+func (p *ankiPlan) reviews() int {
+	n := 0
+	for _, m := range p.Matches {
+		n += len(m.Logs)
+	}
+	return n
+}
+
+func (p *ankiPlan) print(clock fsrs.Clock) {
+	if len(p.Matches) > 0 {
+		sort.Slice(p.Matches, func(i, j int) bool {
+			return p.Matches[i].Topic.Title < p.Matches[j].Topic.Title
+		})
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("Topic", "Reviews", "First", "Last", "Due")
+		for _, m := range p.Matches {
+			table.Append(truncateText(m.Topic.Title, maxTitleWidth), fmt.Sprint(len(m.Logs)),
+				m.Logs[0].ReviewedAt.Format("Jan 2, 2006"), m.Logs[len(m.Logs)-1].ReviewedAt.Format("Jan 2, 2006"),
+				clock.StartOfDay(m.Card.Due).Format("Jan 2, 2006"))
+		}
+		table.Render()
+		fmt.Println()
+	}
+
+	fmt.Printf("Notes: %d | Matched topics: %d | Unmatched notes: %d\n",
+		p.Notes, len(p.Matches)+len(p.Skipped)+len(p.Unrated), len(p.Unmatched))
+	printList("Skipped, already reviewed in recall", p.Skipped)
+	printList("Matched, but never reviewed in Anki", p.Unrated)
+	printList("Unmatched notes", p.Unmatched)
+	fmt.Println()
+}
+
+// printList prints a heading and up to maxUnmatchedListed items.
+func printList(heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("\n%s (%d):\n", heading, len(items))
+	for i, item := range items {
+		if i == maxUnmatchedListed {
+			fmt.Printf("  ... and %d more\n", len(items)-i)
+			break
+		}
+		fmt.Printf("  %s\n", truncateText(item, maxTitleWidth))
+	}
+}
+
+var importObsidianSRCmd = &cobra.Command{
+	Use:   "obsidian-sr",
+	Short: "Seed schedules from the Obsidian Spaced Repetition plugin",
+	Long: `Seed topic schedules from the sr-due, sr-interval and sr-ease frontmatter
+written by the Obsidian Spaced Repetition plugin.
+
+The plugin schedules with SM-2, so each note's interval and ease are
+converted to an approximate FSRS card: stability from the interval,
+difficulty from the ease, and the due date kept as is. Only whole-file
+topics are seeded, as the plugin schedules whole notes. Topics that recall
+has already scheduled are skipped.
+
+The plugin keeps no review history, so the seeded schedule is logged as a
+reschedule that 'recall undo' reverts. 'recall reschedule' leaves it alone
+until the topic has been reviewed in recall, then replays those reviews
+from the seeded card.
+
+Examples:
+  recall import obsidian-sr --dry-run  # Report what would be seeded
+  recall import obsidian-sr`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSeedImport(cmd, srSource)
+	},
+}
+
+var importFrontmatterCmd = &cobra.Command{
+	Use:   "frontmatter",
+	Short: "Rebuild schedules from synced frontmatter",
+	Long: `Rebuild topic schedules from the recall_due, recall_state,
+recall_stability, recall_difficulty and recall_last_review frontmatter
+written with sync_frontmatter on.
+
+Use it when the review data is lost: run 'recall scan' to track the topics
+again, then this command to restore their schedules. Review history cannot
+be restored, so each restored schedule is logged as a reschedule. Topics
+that recall has already scheduled are skipped.
+
+Examples:
+  recall scan && recall import frontmatter --dry-run
+  recall import frontmatter`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSeedImport(cmd, seedSource{Name: "synced", Convert: cardFromFrontmatter})
+	},
+}
+
+// seedCard converts a file's frontmatter to a card. It reports false when
+// the frontmatter has no schedule.
+type seedCard func(fm *parser.Frontmatter, clock fsrs.Clock) (fsrs.Card, bool, error)
+
+// seedSource is a kind of schedule kept in note frontmatter.
+type seedSource struct {
+	Name    string // Names the schedules in the report
+	Convert seedCard
+
+	// Columns and Values add the source's own fields to the report, such
+	// as the SM-2 values a card was converted from. Values is only called
+	// for frontmatter that Convert accepted.
+	Columns []string
+	Values  func(fm *parser.Frontmatter) []string
+}
+
+// runSeedImport seeds unscheduled whole-file topics with the cards source
+// reads from their frontmatter.
+func runSeedImport(cmd *cobra.Command, source seedSource) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	wikiPath, err := getWikiPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	clock, err := cfg.Clock()
+	if err != nil {
+		return err
+	}
+
+	store, err := getStorage()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	now := time.Now()
+	var plan *seedPlan
+	if dryRun {
+		plan = planSeedImport(store, clock, wikiPath, source, now)
+	} else {
+		err = store.Update(func() error {
+			plan = planSeedImport(store, clock, wikiPath, source, now)
+			return applyImport(store, plan.Matches)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	plan.print(clock, source)
+	if dryRun {
+		fmt.Println("Dry run, nothing imported.")
+		return nil
+	}
+	if err := syncFrontmatter(importedTopics(plan.Matches)...); err != nil {
+		return err
+	}
+	fmt.Printf("Seeded %d topics.\n", len(plan.Matches))
+	return nil
+}
+
+// seedPlan is what a frontmatter import would do.
+type seedPlan struct {
+	Matches []importMatch
+	Values  map[string][]string // Source fields by topic ID, for the report
+	Skipped []string            // Topics already scheduled in recall
+	Invalid []string            // Topics with unreadable fields
+}
+
+// planSeedImport converts the frontmatter of every whole-file topic that
+// recall has not scheduled yet.
+func planSeedImport(store storage.Storage, clock fsrs.Clock, wikiPath string, source seedSource, now time.Time) *seedPlan {
+	plan := &seedPlan{Values: make(map[string][]string)}
+	for _, t := range store.GetAllTopics() {
+		if t.Heading != "" || t.Status == storage.StatusArchived {
+			continue
+		}
+		fm, err := parser.ReadFrontmatter(filepath.Join(wikiPath, t.File))
+		if os.IsNotExist(err) {
+			continue // Orphaned; 'recall scan' reports it
+		}
+		if err != nil {
+			plan.Invalid = append(plan.Invalid, fmt.Sprintf("%s: %v", t.Title, err))
+			continue
+		}
+		if fm == nil {
+			continue
+		}
+
+		card, ok, err := source.Convert(fm, clock)
+		if err != nil {
+			plan.Invalid = append(plan.Invalid, fmt.Sprintf("%s: %v", t.Title, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if t.Card.State != fsrs.New || len(store.GetReviewHistory(t.ID)) > 0 {
+			plan.Skipped = append(plan.Skipped, t.Title)
+			continue
+		}
+
+		plan.Matches = append(plan.Matches, importMatch{
+			Topic: t,
+			Logs:  []storage.ReviewLog{storage.NewRescheduleLog(clock, t.ID, "", t.Card, card, now)},
+			Card:  card,
+		})
+		if source.Values != nil {
+			plan.Values[t.ID] = source.Values(fm)
+		}
+	}
+	return plan
+}
+
+// srSource seeds schedules from the Obsidian Spaced Repetition plugin.
+var srSource = seedSource{
+	Name:    "plugin",
+	Convert: srCard,
+	Columns: []string{"Interval", "Ease"},
+	Values:  srValues,
+}
+
+// srDueLayouts are the sr-due formats of current and older plugin
+// versions.
+var srDueLayouts = []string{time.DateOnly, "Mon Jan 02 2006", "02-01-2006"}
+
+// srCard converts the plugin's due date, interval in days and ease.
+func srCard(fm *parser.Frontmatter, clock fsrs.Clock) (fsrs.Card, bool, error) {
+	if fm.SRDue == "" {
+		return fsrs.Card{}, false, nil
+	}
+
+	var due time.Time
+	var err error
+	for _, layout := range srDueLayouts {
+		if due, err = parseDay(clock, layout, fm.SRDue); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fsrs.Card{}, false, fmt.Errorf("invalid sr-due: %s", fm.SRDue)
+	}
+
+	interval, err := strconv.ParseFloat(strings.TrimSpace(fm.SRInterval), 64)
+	if err != nil || interval <= 0 {
+		return fsrs.Card{}, false, fmt.Errorf("invalid sr-interval: %s", fm.SRInterval)
+	}
+	ease, err := strconv.ParseFloat(strings.TrimSpace(fm.SREase), 64)
+	if err != nil || ease <= 0 {
+		return fsrs.Card{}, false, fmt.Errorf("invalid sr-ease: %s", fm.SREase)
+	}
+	return fsrs.FromSM2(clock, interval, ease, due), true, nil
+}
+
+// srValues formats the plugin's interval and ease for the report.
+func srValues(fm *parser.Frontmatter) []string {
+	interval, _ := strconv.ParseFloat(strings.TrimSpace(fm.SRInterval), 64)
+	ease, _ := strconv.ParseFloat(strings.TrimSpace(fm.SREase), 64)
+	return []string{fmt.Sprintf("%.0fd", interval), fmt.Sprintf("%.0f%%", ease)}
+}
+
+// parseDay parses a date and returns the start of that scheduling day.
+func parseDay(clock fsrs.Clock, layout, value string) (time.Time, error) {
+	date, err := time.ParseInLocation(layout, strings.TrimSpace(value), clock.Location)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return clock.StartOfDay(date.Add(time.Duration(clock.DayStart) * time.Hour)), nil
+}
+
+func (p *seedPlan) print(clock fsrs.Clock, source seedSource) {
+	if len(p.Matches) > 0 {
+		sort.Slice(p.Matches, func(i, j int) bool {
+			return p.Matches[i].Topic.Title < p.Matches[j].Topic.Title
+		})
+		table := tablewriter.NewWriter(os.Stdout)
+		header := append([]string{"Topic"}, source.Columns...)
+		table.Header(append(header, "State", "Stability", "Difficulty", "Last review", "Due"))
+		for _, m := range p.Matches {
+			row := append([]string{truncateText(m.Topic.Title, maxTitleWidth)}, p.Values[m.Topic.ID]...)
+			table.Append(append(row, stateName(m.Card.State),
+				fmt.Sprintf("%.1f", m.Card.Stability), fmt.Sprintf("%.1f", m.Card.Difficulty),
+				clock.In(m.Card.LastReview).Format("Jan 2, 2006"),
+				clock.StartOfDay(m.Card.Due).Format("Jan 2, 2006")))
+		}
+		table.Render()
+		fmt.Println()
+	}
+
+	fmt.Printf("Topics with %s schedules: %d | To seed: %d\n",
+		source.Name, len(p.Matches)+len(p.Skipped)+len(p.Invalid), len(p.Matches))
+	printList("Skipped, already scheduled in recall", p.Skipped)
+	printList("Invalid fields", p.Invalid)
+	fmt.Println()
+}
+
+func init() {
+	importAnkiCmd.Flags().String("field", "", "Note field holding the topic title (default: first field)")
+	importAnkiCmd.Flags().String("tag-prefix", "", "Match topics by note tags with this prefix instead")
+	importAnkiCmd.Flags().Bool("dry-run", false, "Report matches without importing")
+	importObsidianSRCmd.Flags().Bool("dry-run", false, "Report schedules without importing")
+	importFrontmatterCmd.Flags().Bool("dry-run", false, "Report schedules without importing")
+	importCmd.AddCommand(importAnkiCmd, importObsidianSRCmd, importFrontmatterCmd)
+	rootCmd.AddCommand(importCmd)
+}
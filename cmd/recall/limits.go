@@ -0,0 +1,162 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+)
+
+// allotment is the part of today's due topics that fits the daily limits.
+type allotment struct {
+	Topics        []storage.Topic // Reviews in due order, then new topics in queue order
+	QueuedNew     int             // New topics held back for later days
+	QueuedReviews int             // Reviews held back by reviews_per_day
+}
+
+// dailyAllotment applies the new_per_day and reviews_per_day limits to
+// topics due today (sorted with sortDueTopics). Topics first read or
+// reviewed earlier today count against the limits. New topics are queued
+// by tag priority, then by when they were added.
+func dailyAllotment(cfg *config.Config, store storage.Storage, clock fsrs.Clock, topics []storage.Topic, now time.Time) allotment {
+	var reviews, unread []storage.Topic
+	for _, t := range topics {
+		if t.Card.State == fsrs.New {
+			unread = append(unread, t)
+		} else {
+			reviews = append(reviews, t)
+		}
+	}
+
+	var priority []string
+	newLimit, reviewLimit := -1, -1
+	if cfg != nil {
+		priority = cfg.TagPriority
+		if cfg.NewPerDay > 0 {
+			newLimit = cfg.NewPerDay
+		}
+		if cfg.ReviewsPerDay > 0 {
+			reviewLimit = cfg.ReviewsPerDay
+		}
+	}
+	sortNewTopics(unread, priority)
+
+	if newLimit >= 0 || reviewLimit >= 0 {
+		readToday, reviewedToday := doneToday(store, clock, now)
+		if newLimit >= 0 {
+			newLimit = max(newLimit-readToday, 0)
+		}
+		if reviewLimit >= 0 {
+			reviewLimit = max(reviewLimit-reviewedToday, 0)
+		}
+	}
+
+	var a allotment
+	if reviewLimit >= 0 && len(reviews) > reviewLimit {
+		a.QueuedReviews = len(reviews) - reviewLimit
+		reviews = reviews[:reviewLimit]
+	}
+	if newLimit >= 0 && len(unread) > newLimit {
+		a.QueuedNew = len(unread) - newLimit
+		unread = unread[:newLimit]
+	}
+	a.Topics = append(reviews, unread...)
+	return a
+}
+
+// withTag narrows an allotment of the due topics to those with the given
+// tag ("" for all), counting only that tag's queued topics.
+func (a allotment) withTag(due []storage.Topic, tag string) allotment {
+	if tag == "" {
+		return a
+	}
+	topics := filterByTag(a.Topics, tag)
+	dueNew, dueReviews := countByState(filterByTag(due, tag))
+	allottedNew, allottedReviews := countByState(topics)
+	return allotment{
+		Topics:        topics,
+		QueuedNew:     dueNew - allottedNew,
+		QueuedReviews: dueReviews - allottedReviews,
+	}
+}
+
+// countByState counts new topics and topics to review.
+func countByState(topics []storage.Topic) (unread, reviews int) {
+	for _, t := range topics {
+		if t.Card.State == fsrs.New {
+			unread++
+		} else {
+			reviews++
+		}
+	}
+	return unread, reviews
+}
+
+// sortNewTopics orders unread topics by the position of their best tag in
+// priority (untagged or unlisted tags last), then by creation time.
+func sortNewTopics(topics []storage.Topic, priority []string) {
+	rank := func(t storage.Topic) int {
+		best := len(priority)
+		for _, tag := range t.Tags {
+			if i := slices.Index(priority, tag); i >= 0 && i < best {
+				best = i
+			}
+		}
+		return best
+	}
+
+	sort.SliceStable(topics, func(i, j int) bool {
+		ri, rj := rank(topics[i]), rank(topics[j])
+		if ri != rj {
+			return ri < rj
+		}
+		if !topics[i].Created.Equal(topics[j].Created) {
+			return topics[i].Created.Before(topics[j].Created)
+		}
+		return topics[i].Title < topics[j].Title
+	})
+}
+
+// doneToday counts topics first read today and topic reviews done today.
+func doneToday(store storage.Storage, clock fsrs.Clock, now time.Time) (read, reviewed int) {
+	today := clock.Date(now)
+	for _, r := range store.GetAllReviews() {
+		if r.CardID != "" || r.Kind == storage.KindReschedule {
+			continue
+		}
+		if clock.Date(r.ReviewedAt) != today {
+			continue
+		}
+		if r.Kind == storage.KindRead {
+			read++
+		} else {
+			reviewed++
+		}
+	}
+	return read, reviewed
+}
+
+// topicsDue returns the topics with the given tag ("" for all) that are
+// due by until: today's daily allotment first, then topics due on later
+// days in due order. The allotment itself is returned too. It is worked
+// out over all topics before filtering, so the limits are shared by all
+// tags rather than applied to each one.
+func topicsDue(cfg *config.Config, store storage.Storage, clock fsrs.Clock, tag string, now, until time.Time) ([]storage.Topic, allotment) {
+	today := clock.EndOfDay(now)
+	dueToday := store.GetDueTopics(today)
+	sortDueTopics(dueToday, clock, now)
+	a := dailyAllotment(cfg, store, clock, dueToday, now).withTag(dueToday, tag)
+
+	topics := slices.Clone(a.Topics)
+	if until.After(today) {
+		later := slices.DeleteFunc(filterByTag(store.GetDueTopics(until), tag), func(t storage.Topic) bool {
+			return !t.DueAt().After(today)
+		})
+		sortDueTopics(later, clock, now)
+		topics = append(topics, later...)
+	}
+	return topics, a
+}
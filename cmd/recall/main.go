@@ -13,6 +13,12 @@ var rootCmd = &cobra.Command{
 	Long:              `Recall helps you remember what you learn by scheduling reviews using the FSRS algorithm.`,
 	SilenceUsage:      true,
 	CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: false},
+	PersistentPreRunE: checkFormat,
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("format", formatTable, "Output format: table, json, csv or tsv")
+	rootCmd.PersistentFlags().Bool("json", false, "Shorthand for --format json")
 }
 
 func main() {
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate --to <backend>",
+	Short: "Move review data to another storage backend",
+	Long: `Copy all topics, flashcards and review history to another storage
+backend and switch the config over to it.
+
+Backends:
+  json    .srs/reviews.json (default)
+  sqlite  .srs/reviews.db, faster for large wikis
+
+Any data already in the target backend is replaced. The source file is
+left in place, so you can switch back with 'recall config set backend'.
+
+Examples:
+  recall migrate --to sqlite
+  recall migrate --to json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		if to != storage.BackendJSON && to != storage.BackendSQLite {
+			return fmt.Errorf("invalid backend: %s (expected json or sqlite)", to)
+		}
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		from := cfg.Backend
+		if from == "" {
+			from = storage.BackendJSON
+		}
+		if from == to {
+			return fmt.Errorf("already using the %s backend", to)
+		}
+
+		wikiPath, err := getWikiPath()
+		if err != nil {
+			return err
+		}
+
+		source, err := storage.Open(wikiPath, from)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+
+		target, err := storage.Open(wikiPath, to)
+		if err != nil {
+			return err
+		}
+		defer target.Close()
+
+		// Export inside the source's Update so no other recall process
+		// changes it mid-copy.
+		var data *storage.Data
+		err = source.Update(func() error {
+			data, err = source.Export()
+			if err != nil {
+				return err
+			}
+			return target.Update(func() error { return target.Import(data) })
+		})
+		if err != nil {
+			return err
+		}
+
+		cfg.Backend = to
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("Migrated %d topics, %d flashcards and %d reviews from %s to %s\n",
+			len(data.Topics), len(data.Flashcards), len(data.Reviews), from, to)
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.Flags().String("to", "", "Target backend (json or sqlite)")
+	migrateCmd.MarkFlagRequired("to")
+	rootCmd.AddCommand(migrateCmd)
+}
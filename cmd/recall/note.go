@@ -25,15 +25,15 @@ Example:
 		}
 
 		title := args[0]
-		filePath, err := findTopicFile(wikiPath, title)
+		loc, err := findTopic(wikiPath, title)
 		if err != nil {
 			return err
 		}
-		if filePath == "" {
+		if loc == nil {
 			return fmt.Errorf("topic not found: %s", title)
 		}
 
-		notes, err := readNotes(filePath)
+		notes, err := readTopicNotes(loc)
 		if err != nil {
 			return err
 		}
@@ -46,6 +46,20 @@ Example:
 	},
 }
 
+// readTopicNotes returns the notes for a topic: the section body for
+// section topics, the whole file otherwise.
+func readTopicNotes(loc *topicLocation) (string, error) {
+	if loc.Heading == "" {
+		return readNotes(loc.File)
+	}
+
+	sec, err := findTopicSection(loc)
+	if err != nil {
+		return "", err
+	}
+	return sec.Body, nil
+}
+
 func readNotes(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/amiraminb/recall/internal/parser"
 	"github.com/spf13/cobra"
 )
 
@@ -32,15 +33,15 @@ Example:
 		}
 
 		title := args[0]
-		filePath, err := findTopicFile(wikiPath, title)
+		loc, err := findTopic(wikiPath, title)
 		if err != nil {
 			return err
 		}
-		if filePath == "" {
+		if loc == nil {
 			return fmt.Errorf("topic not found: %s", title)
 		}
 
-		link, err := findFirstLink(filePath)
+		link, err := findTopicLink(loc)
 		if err != nil {
 			return err
 		}
@@ -57,6 +58,61 @@ Example:
 	},
 }
 
+// topicLocation is where a topic's notes live: a file and, for section
+// topics, a heading within it.
+type topicLocation struct {
+	File    string
+	Heading string
+}
+
+// findTopic resolves a title to its location. Tracked topics are looked up
+// in storage first; otherwise the title is matched against wiki filenames,
+// with "File#Heading" addressing a section of a file.
+func findTopic(wikiPath, title string) (*topicLocation, error) {
+	store, err := getStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+	if topic := store.GetTopicByTitle(title); topic != nil {
+		return &topicLocation{
+			File:    filepath.Join(wikiPath, topic.File),
+			Heading: topic.Heading,
+		}, nil
+	}
+
+	filePath, err := findTopicFile(wikiPath, title)
+	if err != nil {
+		return nil, err
+	}
+	if filePath != "" {
+		return &topicLocation{File: filePath}, nil
+	}
+
+	name, heading, ok := strings.Cut(title, "#")
+	if !ok {
+		return nil, nil
+	}
+	filePath, err = findTopicFile(wikiPath, name)
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+	return &topicLocation{File: filePath, Heading: heading}, nil
+}
+
+// findTopicSection returns the section a location points at, or an error
+// if the heading no longer exists in the file.
+func findTopicSection(loc *topicLocation) (*parser.Section, error) {
+	sec, err := parser.FindSection(loc.File, loc.Heading)
+	if err != nil {
+		return nil, err
+	}
+	if sec == nil {
+		return nil, fmt.Errorf("section not found: %s", loc.Heading)
+	}
+	return sec, nil
+}
+
 func findTopicFile(wikiPath, title string) (string, error) {
 	var matches []string
 	searchName := strings.TrimSpace(title)
@@ -112,6 +168,16 @@ func completeTopicTitles(cmd *cobra.Command, args []string, toComplete string) (
 		return nil, cobra.ShellCompDirectiveError
 	}
 
+	// Section topics are not files, so offer the tracked ones too.
+	if store, err := getStorage(); err == nil {
+		for _, t := range store.GetAllTopics() {
+			if t.Heading != "" {
+				titles = append(titles, t.Title)
+			}
+		}
+		store.Close()
+	}
+
 	prefix := strings.ToLower(toComplete)
 	var matches []string
 	for _, title := range titles {
@@ -123,6 +189,26 @@ func completeTopicTitles(cmd *cobra.Command, args []string, toComplete string) (
 	return matches, cobra.ShellCompDirectiveNoFileComp
 }
 
+// findTopicLink returns the first markdown link in a topic, looking only
+// inside the section for section topics.
+func findTopicLink(loc *topicLocation) (string, error) {
+	if loc.Heading == "" {
+		return findFirstLink(loc.File)
+	}
+
+	sec, err := findTopicSection(loc)
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(sec.Body, "\n") {
+		matches := markdownLinkRegex.FindStringSubmatch(line)
+		if len(matches) > 1 {
+			return strings.TrimSpace(matches[1]), nil
+		}
+	}
+	return "", nil
+}
+
 func findFirstLink(filePath string) (string, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var optimizeCmd = &cobra.Command{
+	Use:   "optimize",
+	Short: "Fit FSRS weights to your review history",
+	Long: `Train the FSRS model weights on your own review history.
+
+Each topic's ratings are replayed in order and the weights are tuned to
+minimize the log-loss of the predicted recall probability. The fitted
+weights are saved to your config and used for all future scheduling.
+
+Existing cards keep their current due dates until they are next reviewed,
+or until 'recall reschedule --all' replays them with the new weights.
+
+Examples:
+  recall optimize            # Fit and save weights
+  recall optimize --dry-run  # Only report how much the fit would improve
+  recall optimize --reset    # Go back to the default weights`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		reset, _ := cmd.Flags().GetBool("reset")
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		if reset {
+			cfg.Weights = nil
+			if err := config.Save(cfg); err != nil {
+				return err
+			}
+			fmt.Println("Restored default FSRS weights.")
+			return nil
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		scheduler, err := schedulerFor(cfg, nil)
+		if err != nil {
+			return err
+		}
+
+		histories := reviewHistories(store.GetAllReviews())
+
+		fmt.Printf("Optimizing on %d cards...\n\n", len(histories))
+
+		before := fsrs.Evaluate(scheduler.Params, scheduler.Clock, histories)
+		params, err := fsrs.Optimize(scheduler.Params, scheduler.Clock, histories)
+		if err != nil {
+			return err
+		}
+		after := fsrs.Evaluate(params, scheduler.Clock, histories)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("Metric", "Before", "After")
+		table.Append("Log loss", fmt.Sprintf("%.4f", before.LogLoss), fmt.Sprintf("%.4f", after.LogLoss))
+		table.Append("RMSE", fmt.Sprintf("%.4f", before.RMSE), fmt.Sprintf("%.4f", after.RMSE))
+		table.Append("Reviews", fmt.Sprint(before.Reviews), fmt.Sprint(after.Reviews))
+		table.Render()
+
+		fmt.Printf("\nWeights: %s\n", formatWeights(params.W))
+
+		if dryRun {
+			fmt.Println("\nDry run, weights not saved.")
+			return nil
+		}
+
+		cfg.Weights = params.W
+		if err := config.Save(cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nSaved weights to %s\n", config.DefaultConfigPath())
+		return nil
+	},
+}
+
+// reviewHistories groups the ratings in review logs by card (a topic or
+// one of its flashcards), each sorted by time.
+func reviewHistories(reviews []storage.ReviewLog) [][]fsrs.ReviewEvent {
+	byCard := make(map[string][]fsrs.ReviewEvent)
+	var order []string
+	for _, r := range reviews {
+		if r.Kind == storage.KindReschedule {
+			continue
+		}
+		key := r.TopicID + ":" + r.CardID
+		if _, ok := byCard[key]; !ok {
+			order = append(order, key)
+		}
+		byCard[key] = append(byCard[key], reviewEvent(r))
+	}
+
+	histories := make([][]fsrs.ReviewEvent, 0, len(order))
+	for _, key := range order {
+		h := byCard[key]
+		sort.SliceStable(h, func(i, j int) bool { return h[i].Time.Before(h[j].Time) })
+		histories = append(histories, h)
+	}
+	return histories
+}
+
+// reviewEvent is the rating in a review log as a replayable event. It
+// keeps the card before the rating, so a schedule seeded by an import is
+// replayed from the seeded card.
+func reviewEvent(r storage.ReviewLog) fsrs.ReviewEvent {
+	return fsrs.ReviewEvent{Rating: r.Rating, Time: r.ReviewedAt, Before: r.Before}
+}
+
+func formatWeights(w []float64) string {
+	s := ""
+	for i, v := range w {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%.4f", v)
+	}
+	return s
+}
+
+func init() {
+	optimizeCmd.Flags().Bool("dry-run", false, "Report results without saving weights")
+	optimizeCmd.Flags().Bool("reset", false, "Restore the default weights")
+	rootCmd.AddCommand(optimizeCmd)
+}
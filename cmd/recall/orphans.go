@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/parser"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// maxRelinkCandidates is how many files --relink offers per orphan.
+	maxRelinkCandidates = 5
+	// minRelinkScore filters out candidates that are clearly unrelated.
+	minRelinkScore = 0.2
+)
+
+// handleOrphans reports orphaned topics or prunes or archives them,
+// depending on the scan flags. It runs inside the scan's storage update.
+// With --relink the orphans are left to relinkOrphans, which asks its
+// questions after the scan has released the lock.
+func handleOrphans(cmd *cobra.Command, store storage.Storage, log *scanLog, orphans []storage.Topic) error {
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	prune, _ := cmd.Flags().GetBool("prune")
+	archive, _ := cmd.Flags().GetBool("archive")
+	relink, _ := cmd.Flags().GetBool("relink")
+
+	switch {
+	case prune:
+		for _, o := range orphans {
+			if err := store.RemoveTopic(o.ID, false); err != nil {
+				return err
+			}
+			log.add("pruned", &o, "", fmt.Sprintf("  - %s (pruned)", o.Title))
+		}
+		log.printf("\nPruned %d orphaned topics\n", len(orphans))
+		return nil
+
+	case archive:
+		for _, o := range orphans {
+			o.Status = storage.StatusArchived
+			if err := store.UpdateTopic(&o); err != nil {
+				return err
+			}
+			log.add("archived", &o, "", fmt.Sprintf("  - %s (archived)", o.Title))
+		}
+		log.printf("\nArchived %d orphaned topics\n", len(orphans))
+		return nil
+
+	case relink:
+		return nil
+	}
+
+	reportOrphans(log, orphans)
+	return nil
+}
+
+func reportOrphans(log *scanLog, orphans []storage.Topic) {
+	if len(orphans) == 0 {
+		return
+	}
+	log.printf("\nOrphaned topics (%d):\n", len(orphans))
+	for _, o := range orphans {
+		log.add("orphaned", &o, "", fmt.Sprintf("  ? %s", o.Title))
+	}
+	log.printf("\nUse 'recall scan --prune', '--archive' or '--relink' to clean up.\n")
+}
+
+type relinkCandidate struct {
+	topicID string
+	title   string
+	file    string
+	score   float64
+}
+
+// relinkOrphans asks, for each orphan, which scanned topic it became. Only
+// topics that were never read are offered, since relinking replaces the
+// target's (empty) history with the orphan's. The questions are asked
+// without holding the storage lock; the answers are then applied in one
+// update, skipping any target that was read in the meantime.
+func relinkOrphans(store storage.Storage, log *scanLog, orphans []storage.Topic, scanned []parser.ParsedTopic, relPaths []string) error {
+	in := bufio.NewReader(os.Stdin)
+	chosen := make(map[string]relinkCandidate) // Orphan ID to target
+	var remaining []storage.Topic
+
+	for _, o := range orphans {
+		candidates := relinkCandidates(store, o, scanned, relPaths)
+		if len(candidates) == 0 {
+			remaining = append(remaining, o)
+			continue
+		}
+
+		fmt.Printf("\nOrphan: %s (%s)\n", o.Title, o.File)
+		for i, c := range candidates {
+			fmt.Printf("  %d) %s (%s) %.0f%%\n", i+1, c.title, c.file, c.score*100)
+		}
+		fmt.Printf("\nRelink to [1-%d, s=skip]: ", len(candidates))
+
+		input, _ := in.ReadString('\n')
+		n, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || n < 1 || n > len(candidates) {
+			remaining = append(remaining, o)
+			continue
+		}
+		chosen[o.ID] = candidates[n-1]
+	}
+
+	err := store.Update(func() error {
+		for _, o := range orphans {
+			c, ok := chosen[o.ID]
+			if !ok {
+				continue
+			}
+			if !relinkable(store, c.topicID) || store.GetTopic(o.ID) == nil {
+				fmt.Printf("  ! %s: %s changed since the scan, skipped\n", o.Title, c.title)
+				remaining = append(remaining, o)
+				continue
+			}
+			if _, err := store.RelinkTopic(o.ID, c.topicID); err != nil {
+				return err
+			}
+			fmt.Printf("  R %s -> %s\n", o.Title, c.title)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	reportOrphans(log, remaining)
+	return nil
+}
+
+// relinkable reports whether a topic can take over an orphan's history:
+// it exists and has never been read.
+func relinkable(store storage.Storage, id string) bool {
+	topic := store.GetTopic(id)
+	return topic != nil && topic.Card.State == fsrs.New && len(store.GetReviewHistory(id)) == 0
+}
+
+// relinkCandidates ranks unread scanned topics by how likely they are to
+// be the orphan's new location, using content and title similarity.
+func relinkCandidates(store storage.Storage, orphan storage.Topic, scanned []parser.ParsedTopic, relPaths []string) []relinkCandidate {
+	var candidates []relinkCandidate
+	for i, t := range scanned {
+		topic := store.GetTopicByTitle(t.Title)
+		if topic == nil || topic.ID == orphan.ID || !relinkable(store, topic.ID) {
+			continue
+		}
+
+		score := max(
+			contentSimilarity(orphan.Fingerprint, t.Fingerprint),
+			titleSimilarity(orphan.Title, t.Title),
+		)
+		if score < minRelinkScore {
+			continue
+		}
+		candidates = append(candidates, relinkCandidate{
+			topicID: topic.ID,
+			title:   t.Title,
+			file:    relPaths[i],
+			score:   score,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	if len(candidates) > maxRelinkCandidates {
+		candidates = candidates[:maxRelinkCandidates]
+	}
+	return candidates
+}
+
+// contentSimilarity rescales parser.Similarity, which is about 0.5 for
+// unrelated notes (half the bits differ), so that unrelated is 0 and it can
+// be ranked against titleSimilarity.
+func contentSimilarity(a, b uint64) float64 {
+	return max(0, 2*parser.Similarity(a, b)-1)
+}
+
+// titleSimilarity is 1 minus the normalized edit distance of two titles,
+// ignoring case.
+func titleSimilarity(a, b string) float64 {
+	ra := []rune(strings.ToLower(a))
+	rb := []rune(strings.ToLower(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+)
+
+// errTopicChanged means another recall process rated, suspended or
+// archived a topic or flashcard while it was shown.
+var errTopicChanged = errors.New("changed since it was shown")
+
+// rateTopic rates the topic with the given ID. seen is the card's
+// LastReview when the topic was shown; the topic is reloaded inside the
+// storage lock and the rating is refused with errTopicChanged if it has
+// been reviewed or deactivated since. It returns the topic before and
+// after the rating.
+func rateTopic(cfg *config.Config, store storage.Storage, id string, seen time.Time, rating fsrs.Rating, now time.Time, duration time.Duration) (before, after storage.Topic, err error) {
+	err = store.Update(func() error {
+		topic := store.GetTopic(id)
+		if topic == nil {
+			return fmt.Errorf("topic not found: %s", id)
+		}
+		if !topic.IsActive() {
+			return fmt.Errorf("%s %w: it is %s", topic.Title, errTopicChanged, topic.Status)
+		}
+		if !topic.Card.LastReview.Equal(seen) {
+			return fmt.Errorf("%s %w: it was rated elsewhere", topic.Title, errTopicChanged)
+		}
+		before = *topic
+
+		card := topic.Card
+		if card.State == fsrs.New {
+			card = fsrs.NewCard()
+		}
+		scheduler, err := reviewScheduler(cfg, store, topic.Tags)
+		if err != nil {
+			return err
+		}
+		topic.Card = scheduler.Review(card, rating, now)
+		if err := store.UpdateTopic(topic); err != nil {
+			return err
+		}
+		after = *topic
+		return store.AddReview(storage.NewReviewLog(scheduler.Clock, topic.ID, "", rating, before.Card, topic.Card, duration))
+	})
+	if err != nil {
+		return before, after, err
+	}
+	return before, after, syncFrontmatter(after)
+}
+
+// rateFlashcard is rateTopic for a flashcard; tags are its topic's.
+func rateFlashcard(cfg *config.Config, store storage.Storage, id string, tags []string, seen time.Time, rating fsrs.Rating, now time.Time, duration time.Duration) (after storage.Flashcard, err error) {
+	err = store.Update(func() error {
+		card := store.GetFlashcard(id)
+		if card == nil {
+			return fmt.Errorf("flashcard not found: %s", id)
+		}
+		if !card.Card.LastReview.Equal(seen) {
+			return fmt.Errorf("flashcard %w: it was rated elsewhere", errTopicChanged)
+		}
+
+		scheduler, err := reviewScheduler(cfg, store, tags)
+		if err != nil {
+			return err
+		}
+		before := card.Card
+		card.Card = scheduler.Review(card.Card, rating, now)
+		if err := store.UpdateFlashcard(card); err != nil {
+			return err
+		}
+		after = *card
+		return store.AddReview(storage.NewReviewLog(scheduler.Clock, card.TopicID, card.ID, rating, before, card.Card, duration))
+	})
+	return after, err
+}
+
+// updateTopic reloads the topic with the given ID inside the storage
+// lock, applies fn and saves it, so concurrent changes to other fields
+// are kept. It returns the saved topic.
+func updateTopic(store storage.Storage, id string, fn func(*storage.Topic) error) (*storage.Topic, error) {
+	var topic *storage.Topic
+	err := store.Update(func() error {
+		topic = store.GetTopic(id)
+		if topic == nil {
+			return fmt.Errorf("topic not found: %s", id)
+		}
+		if err := fn(topic); err != nil {
+			return err
+		}
+		return store.UpdateTopic(topic)
+	})
+	return topic, err
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/amiraminb/recall/internal/config"
 	"github.com/amiraminb/recall/internal/fsrs"
 	"github.com/spf13/cobra"
 )
@@ -32,6 +33,7 @@ Example:
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		title := args[0]
 		topic := store.GetTopicByTitle(title)
@@ -45,11 +47,8 @@ Example:
 		}
 
 		fmt.Printf("First read: %s\n\n", topic.Title)
-		fmt.Println("How well did you understand this topic?")
-		fmt.Println("  1) Didn't understand")
-		fmt.Println("  2) Partially understood")
-		fmt.Println("  3) Understood well")
-		fmt.Println("  4) Mastered it")
+		shown := time.Now()
+		printUnderstandingOptions()
 		fmt.Print("\nUnderstanding [1-4]: ")
 
 		var input int
@@ -60,24 +59,30 @@ Example:
 		}
 
 		// Initialize card using FSRS on first read
-		now := time.Now()
-		scheduler := fsrs.NewScheduler()
-		topic.Card = scheduler.Review(fsrs.NewCard(), fsrs.Rating(input), now)
-
-		if err := store.UpdateTopic(topic); err != nil {
+		cfg, err := config.Load()
+		if err != nil {
 			return err
 		}
-
-		// Log first-read self rating for history.
-		if err := store.AddReview(topic.ID, fsrs.Rating(input)); err != nil {
+		now := time.Now()
+		_, after, err := rateTopic(cfg, store, topic.ID, topic.Card.LastReview, fsrs.Rating(input), now, now.Sub(shown))
+		if err != nil {
 			return err
 		}
+		topic = &after
 
 		fmt.Printf("\nMarked as read! First review: %s\n", topic.Card.Due.Format("Jan 2, 2006"))
 		return nil
 	},
 }
 
+func printUnderstandingOptions() {
+	fmt.Println("How well did you understand this topic?")
+	fmt.Println("  1) Didn't understand")
+	fmt.Println("  2) Partially understood")
+	fmt.Println("  3) Understood well")
+	fmt.Println("  4) Mastered it")
+}
+
 func init() {
 	rootCmd.AddCommand(readCmd)
 }
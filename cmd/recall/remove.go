@@ -17,8 +17,12 @@ or topics you no longer want to review.
 Note: This only removes the topic from recall's tracking. Your actual
 note file is not affected.
 
+The topic's review history is deleted as well. Pass --keep-history to
+keep the review log entries, e.g. as training data for 'recall optimize'.
+
 Example:
-  recall remove "Old Topic"`,
+  recall remove "Old Topic"
+  recall remove --keep-history "Old Topic"`,
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: completeTopicTitles,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -26,6 +30,7 @@ Example:
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		title := args[0]
 		topic := store.GetTopicByTitle(title)
@@ -33,7 +38,11 @@ Example:
 			return fmt.Errorf("topic not found: %s", title)
 		}
 
-		if err := store.RemoveTopic(topic.ID); err != nil {
+		keepHistory, _ := cmd.Flags().GetBool("keep-history")
+		err = store.Update(func() error {
+			return store.RemoveTopic(topic.ID, keepHistory)
+		})
+		if err != nil {
 			return err
 		}
 
@@ -43,5 +52,6 @@ Example:
 }
 
 func init() {
+	removeCmd.Flags().Bool("keep-history", false, "Keep the topic's review log entries")
 	rootCmd.AddCommand(removeCmd)
 }
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var rescheduleCmd = &cobra.Command{
+	Use:   "reschedule",
+	Short: "Recompute schedules by replaying review history",
+	Long: `Rebuild the FSRS state of topics and their flashcards by replaying their
+review history through the current scheduler. Schedules seeded by 'recall
+import obsidian-sr' or 'recall import frontmatter' are replayed from the
+seeded card rather than from scratch.
+
+Card state is stored separately from the review log, so after changing
+weights, desired retention or per-tag settings (or after a scheduler fix),
+existing cards keep schedules computed the old way until their next
+review. Rescheduling brings them up to date. Fuzz and load balancing are
+not applied to replayed intervals.
+
+The table shows how each changed card's due date moves. Every change is
+logged and can be reverted with 'recall undo', one card at a time.
+
+Examples:
+  recall reschedule --all --dry-run   # Preview how due dates would move
+  recall reschedule --all             # Reschedule everything
+  recall reschedule --tag k8s         # Only k8s-tagged topics`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tag, _ := cmd.Flags().GetString("tag")
+		all, _ := cmd.Flags().GetBool("all")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if tag == "" && !all {
+			return fmt.Errorf("specify --tag or --all")
+		}
+		if tag != "" && all {
+			return fmt.Errorf("--tag and --all cannot be combined")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		now := time.Now()
+		var changes []scheduleChange
+		var replayed int
+		if dryRun {
+			changes, replayed, err = replaySchedules(cfg, store, tag)
+		} else {
+			err = store.Update(func() error {
+				changes, replayed, err = replaySchedules(cfg, store, tag)
+				if err != nil {
+					return err
+				}
+				return applySchedules(store, clock, changes, now)
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("All %d reviewed cards are up to date.\n", replayed)
+			return nil
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("Topic", "State", "Due", "New due", "Shift")
+		for _, c := range changes {
+			table.Append(c.Title, stateName(c.After.State),
+				clock.StartOfDay(c.Before.Due).Format("Jan 2, 2006"),
+				clock.StartOfDay(c.After.Due).Format("Jan 2, 2006"), c.shift(clock))
+		}
+		table.Render()
+
+		if dryRun {
+			fmt.Printf("\n%d of %d reviewed cards would change. Dry run, nothing saved.\n", len(changes), replayed)
+			return nil
+		}
+		var topics []storage.Topic
+		for _, c := range changes {
+			if t := store.GetTopic(c.TopicID); c.CardID == "" && t != nil {
+				topics = append(topics, *t)
+			}
+		}
+		if err := syncFrontmatter(topics...); err != nil {
+			return err
+		}
+		fmt.Printf("\nRescheduled %d of %d reviewed cards.\n", len(changes), replayed)
+		return nil
+	},
+}
+
+// scheduleChange is a topic (CardID empty) or flashcard whose replayed
+// card differs from the stored one.
+type scheduleChange struct {
+	TopicID string
+	CardID  string
+	Title   string
+	Before  fsrs.Card
+	After   fsrs.Card
+}
+
+// shift is how many days the due date moves, e.g. "+3d".
+func (c scheduleChange) shift(clock fsrs.Clock) string {
+	days := clock.Days(c.Before.Due, c.After.Due)
+	if days > 0 {
+		return fmt.Sprintf("+%dd", days)
+	}
+	return fmt.Sprintf("%dd", days)
+}
+
+// replaySchedules replays the history of every non-archived topic with
+// the given tag ("" for all) and of its flashcards. It returns the cards
+// that would change and how many cards had history to replay.
+func replaySchedules(cfg *config.Config, store storage.Storage, tag string) ([]scheduleChange, int, error) {
+	histories := make(map[string][]fsrs.ReviewEvent)
+	for _, r := range store.GetAllReviews() {
+		if r.Kind == storage.KindReschedule {
+			continue
+		}
+		key := r.TopicID + ":" + r.CardID
+		histories[key] = append(histories[key], reviewEvent(r))
+	}
+
+	var changes []scheduleChange
+	var replayed int
+	replay := func(scheduler *fsrs.FSRS, topicID, cardID, title string, card fsrs.Card) {
+		history := histories[topicID+":"+cardID]
+		if len(history) == 0 {
+			return
+		}
+		sortEvents(history)
+		replayed++
+
+		after := scheduler.Replay(history)
+		if !sameSchedule(card, after) {
+			changes = append(changes, scheduleChange{topicID, cardID, title, card, after})
+		}
+	}
+
+	for _, t := range filterByTag(store.GetAllTopics(), tag) {
+		if t.Status == storage.StatusArchived {
+			continue
+		}
+		scheduler, err := schedulerFor(cfg, t.Tags)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		replay(scheduler, t.ID, "", t.Title, t.Card)
+		for _, c := range store.GetFlashcards(t.ID) {
+			replay(scheduler, t.ID, c.ID, fmt.Sprintf("%s (Q: %s)", t.Title, c.Prompt), c.Card)
+		}
+	}
+	return changes, replayed, nil
+}
+
+// applySchedules stores the replayed cards and logs each change so it can
+// be undone. Call it inside store.Update.
+func applySchedules(store storage.Storage, clock fsrs.Clock, changes []scheduleChange, now time.Time) error {
+	for _, c := range changes {
+		if c.CardID == "" {
+			topic := store.GetTopic(c.TopicID)
+			if topic == nil {
+				return fmt.Errorf("topic not found: %s", c.TopicID)
+			}
+			topic.Card = c.After
+			if err := store.UpdateTopic(topic); err != nil {
+				return err
+			}
+		} else {
+			fc := store.GetFlashcard(c.CardID)
+			if fc == nil {
+				return fmt.Errorf("flashcard not found: %s", c.CardID)
+			}
+			fc.Card = c.After
+			if err := store.UpdateFlashcard(fc); err != nil {
+				return err
+			}
+		}
+		if err := store.AddReview(storage.NewRescheduleLog(clock, c.TopicID, c.CardID, c.Before, c.After, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortEvents(events []fsrs.ReviewEvent) {
+	slices.SortStableFunc(events, func(a, b fsrs.ReviewEvent) int {
+		return a.Time.Compare(b.Time)
+	})
+}
+
+// sameSchedule reports whether two cards are due at the same time with
+// the same memory state. Small differences are ignored: older versions
+// logged review times a moment after scheduling, and stored floats are
+// rounded.
+func sameSchedule(a, b fsrs.Card) bool {
+	const epsilon = 1e-6
+	return a.Due.Sub(b.Due).Abs() < time.Minute && a.State == b.State &&
+		a.Reps == b.Reps && a.Lapses == b.Lapses &&
+		math.Abs(a.Stability-b.Stability) < epsilon &&
+		math.Abs(a.Difficulty-b.Difficulty) < epsilon
+}
+
+func init() {
+	rescheduleCmd.Flags().String("tag", "", "Only reschedule topics with this tag")
+	rescheduleCmd.Flags().Bool("all", false, "Reschedule all topics")
+	rescheduleCmd.Flags().Bool("dry-run", false, "Show how due dates would move without saving")
+	rootCmd.AddCommand(rescheduleCmd)
+}
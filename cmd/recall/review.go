@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/amiraminb/recall/internal/config"
 	"github.com/amiraminb/recall/internal/fsrs"
 	"github.com/spf13/cobra"
 )
@@ -32,6 +33,7 @@ Example:
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		title := args[0]
 		topic := store.GetTopicByTitle(title)
@@ -45,11 +47,8 @@ Example:
 		}
 
 		fmt.Printf("Reviewing: %s\n\n", topic.Title)
-		fmt.Println("How well did you recall this topic?")
-		fmt.Println("  1) Again - Forgot completely")
-		fmt.Println("  2) Hard  - Difficult to recall")
-		fmt.Println("  3) Good  - Recalled with effort")
-		fmt.Println("  4) Easy  - Recalled effortlessly")
+		shown := time.Now()
+		printRatingOptions()
 		fmt.Print("\nRating [1-4]: ")
 
 		var input int
@@ -59,22 +58,30 @@ Example:
 			return fmt.Errorf("invalid rating: %d", input)
 		}
 
-		rating := fsrs.Rating(input)
-		scheduler := fsrs.NewScheduler()
-		topic.Card = scheduler.Review(topic.Card, rating, time.Now())
-
-		if err := store.UpdateTopic(topic); err != nil {
+		cfg, err := config.Load()
+		if err != nil {
 			return err
 		}
-		if err := store.AddReview(topic.ID, rating); err != nil {
+		now := time.Now()
+		_, after, err := rateTopic(cfg, store, topic.ID, topic.Card.LastReview, fsrs.Rating(input), now, now.Sub(shown))
+		if err != nil {
 			return err
 		}
+		topic = &after
 
 		fmt.Printf("\nReviewed! Next review: %s\n", topic.Card.Due.Format("Jan 2, 2006"))
 		return nil
 	},
 }
 
+func printRatingOptions() {
+	fmt.Println("How well did you recall this topic?")
+	fmt.Println("  1) Again - Forgot completely")
+	fmt.Println("  2) Hard  - Difficult to recall")
+	fmt.Println("  3) Good  - Recalled with effort")
+	fmt.Println("  4) Easy  - Recalled effortlessly")
+}
+
 func init() {
 	rootCmd.AddCommand(reviewCmd)
 }
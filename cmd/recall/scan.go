@@ -1,15 +1,24 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"slices"
+	"sort"
 	"strings"
 
+	"github.com/amiraminb/recall/internal/fsrs"
 	"github.com/amiraminb/recall/internal/parser"
+	"github.com/amiraminb/recall/internal/storage"
 	"github.com/spf13/cobra"
 )
 
+// minRenameSimilarity is how alike an orphan's last known content and a new
+// topic must be before scan treats the new topic as a rename.
+const minRenameSimilarity = 0.85
+
 var scanCmd = &cobra.Command{
 	Use:   "scan",
 	Short: "Scan wiki for topics to review",
@@ -18,9 +27,31 @@ var scanCmd = &cobra.Command{
 This command:
   - Discovers new topics and adds them to tracking
   - Updates tags if they've changed in the frontmatter
-  - Detects orphaned topics (renamed or deleted files)
+  - Extracts flashcards (Q:/A:, "question :: answer", {{c1::cloze}})
+  - Detects renamed or moved files and keeps their review history
+  - Detects orphaned topics (deleted files)
+  - Suspends topics marked 'review: suspended' (and resumes them when the
+    mark is removed)
+  - Writes the schedule of read topics into their frontmatter when
+    sync_frontmatter is on
+
+Renames are recognized by the 'uid' frontmatter field, by an unchanged
+file path (when only the title or id changed), or by content similarity.
+Use --write-uid to add a uid to every tracked file so renames are always
+detected.
+
+Orphaned topics (whose file is gone) are listed by default. Use one of:
+  --prune    Remove them along with their review history
+  --archive  Keep them and their history, but stop scheduling them
+  --relink   Pick the file each orphan now lives in from a list of likely
+             matches; the orphan's history moves to that topic
 
-Run this after adding new notes or modifying tags.`,
+Archived topics come back automatically if their file reappears.
+
+Run this after adding new notes or modifying tags. With --format json,
+csv or tsv, scan prints one record per change instead (--relink is
+interactive and cannot be combined with it).`,
+	Annotations: supportsFormat,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		wikiPath, err := getWikiPath()
 		if err != nil {
@@ -31,61 +62,347 @@ Run this after adding new notes or modifying tags.`,
 		if err != nil {
 			return err
 		}
+		defer store.Close()
+
+		writeUID, _ := cmd.Flags().GetBool("write-uid")
+		log := &scanLog{text: outputFormat(cmd) == formatTable}
+		if relink, _ := cmd.Flags().GetBool("relink"); relink && !log.text {
+			return fmt.Errorf("--relink cannot be combined with --format %s", outputFormat(cmd))
+		}
 
-		topics, err := parser.ScanDirectory(wikiPath)
+		topics, warnings, err := parser.ScanDirectory(wikiPath)
 		if err != nil {
 			return err
 		}
+		for _, w := range warnings {
+			rel, _ := filepath.Rel(wikiPath, w.File)
+			cmd.PrintErrf("warning: %s: %s\n", rel, w.Message)
+		}
+
+		relPaths := make([]string, len(topics))
+		for i, t := range topics {
+			relPaths[i], _ = filepath.Rel(wikiPath, t.File)
+		}
+
+		// Hold the storage lock for the whole scan so renames, additions
+		// and orphan cleanup are saved together.
+		var orphans []storage.Topic
+		err = store.Update(func() error {
+			matches := matchTopics(store, topics, relPaths)
+
+			// Track which existing topics were found
+			found := make(map[string]bool)
+
+			added := 0
+			updated := 0
+			renamed := 0
+			for i, t := range topics {
+				relPath := relPaths[i]
+
+				var existing *storage.Topic
+				if id := matches[i]; id != "" {
+					existing = store.GetTopic(id)
+				}
+
+				if existing == nil {
+					// New topic
+					topic, err := store.AddTopic(t.Title, relPath, t.Heading, t.Tags)
+					if err != nil {
+						return err
+					}
+					existing = topic
+					added++
+					log.add("added", existing, "",
+						fmt.Sprintf("  + %s [%s]", t.Title, strings.Join(t.Tags, ", ")))
+				} else {
+					if existing.Title != t.Title || existing.File != relPath || existing.Heading != t.Heading {
+						from, to := existing.Title, t.Title
+						if from == to {
+							from, to = existing.File, relPath
+						}
+						topic, err := store.MoveTopic(existing.ID, t.Title, relPath, t.Heading)
+						if err != nil {
+							return err
+						}
+						existing = topic
+						renamed++
+						log.add("renamed", existing, from,
+							fmt.Sprintf("  R %s -> %s", from, to))
+					}
+
+					// Check if tags changed
+					if !slices.Equal(existing.Tags, t.Tags) {
+						existing.Tags = t.Tags
+						store.UpdateTopic(existing)
+						updated++
+						log.add("updated", existing, strings.Join(t.Tags, ","),
+							fmt.Sprintf("  ~ %s [%s]", t.Title, strings.Join(t.Tags, ", ")))
+					}
+				}
+				found[existing.ID] = true
+
+				if existing.Status == storage.StatusArchived {
+					existing.Status = storage.StatusActive
+					if err := store.UpdateTopic(existing); err != nil {
+						return err
+					}
+					log.add("restored", existing, "",
+						fmt.Sprintf("  ^ %s (restored from archive)", t.Title))
+				}
+
+				if t.Suspended && existing.Status == storage.StatusActive {
+					existing.Status = storage.StatusSuspended
+					existing.NoteSuspended = true
+					if err := store.UpdateTopic(existing); err != nil {
+						return err
+					}
+					log.add("suspended", existing, "",
+						fmt.Sprintf("  ! %s (suspended)", t.Title))
+				} else if !t.Suspended && existing.NoteSuspended {
+					existing.Status = storage.StatusActive
+					existing.NoteSuspended = false
+					if err := store.UpdateTopic(existing); err != nil {
+						return err
+					}
+					log.add("unsuspended", existing, "",
+						fmt.Sprintf("  ^ %s (unsuspended)", t.Title))
+				}
+
+				if existing.UID != t.UID || existing.Fingerprint != t.Fingerprint {
+					existing.UID = t.UID
+					existing.Fingerprint = t.Fingerprint
+					if err := store.UpdateTopic(existing); err != nil {
+						return err
+					}
+				}
+
+				cardsAdded, cardsRemoved, err := store.SyncFlashcards(existing.ID, toFlashcards(t.Flashcards))
+				if err != nil {
+					return err
+				}
+				if cardsAdded > 0 || cardsRemoved > 0 {
+					log.add("cards", existing, fmt.Sprintf("+%d,-%d", cardsAdded, cardsRemoved),
+						fmt.Sprintf("  * %s: %d cards (+%d, -%d)", t.Title, len(t.Flashcards), cardsAdded, cardsRemoved))
+				}
+			}
+
+			if writeUID {
+				if err := writeMissingUIDs(store, log, topics, relPaths); err != nil {
+					return err
+				}
+			}
 
-		// Track which existing topics were found
-		foundTitles := make(map[string]bool)
-
-		added := 0
-		updated := 0
-		for _, t := range topics {
-			relPath, _ := filepath.Rel(wikiPath, t.File)
-			foundTitles[t.Title] = true
-
-			existing := store.GetTopicByTitle(t.Title)
-			if existing == nil {
-				// New topic
-				store.AddTopic(t.Title, relPath, t.Tags)
-				added++
-				fmt.Printf("  + %s [%s]\n", t.Title, strings.Join(t.Tags, ", "))
-			} else {
-				// Check if tags changed
-				if !slices.Equal(existing.Tags, t.Tags) {
-					existing.Tags = t.Tags
-					store.UpdateTopic(existing)
-					updated++
-					fmt.Printf("  ~ %s [%s]\n", t.Title, strings.Join(t.Tags, ", "))
+			// Detect orphans
+			orphans = nil
+			for _, t := range store.GetAllTopics() {
+				if !found[t.ID] && t.Status != storage.StatusArchived {
+					orphans = append(orphans, t)
 				}
 			}
+
+			log.printf("\nScanned: %d topics | Added: %d | Updated: %d | Renamed: %d\n",
+				len(topics), added, updated, renamed)
+
+			return handleOrphans(cmd, store, log, orphans)
+		})
+		if err != nil {
+			return err
+		}
+		if relink, _ := cmd.Flags().GetBool("relink"); relink && len(orphans) > 0 {
+			if err := relinkOrphans(store, log, orphans, topics, relPaths); err != nil {
+				return err
+			}
 		}
 
-		// Detect orphans
-		existingTopics := store.GetAllTopics()
-		var orphans []string
-		for _, t := range existingTopics {
-			if !foundTitles[t.Title] {
-				orphans = append(orphans, t.Title)
+		// Unread topics are left alone, so a scan after losing the review
+		// data keeps the synced schedules for 'recall import frontmatter'.
+		var scheduled []storage.Topic
+		for _, t := range store.GetAllTopics() {
+			if t.Card.State != fsrs.New {
+				scheduled = append(scheduled, t)
 			}
 		}
+		if err := syncFrontmatter(scheduled...); err != nil || log.text {
+			return err
+		}
+		return printRecords(cmd, scanHeader, log.records)
+	},
+}
 
-		fmt.Printf("\nScanned: %d topics | Added: %d | Updated: %d\n", len(topics), added, updated)
+// scanRecord is the schema for one change made by scan.
+type scanRecord struct {
+	Action  string `json:"action"`
+	TopicID string `json:"topic_id"`
+	Title   string `json:"title"`
+	File    string `json:"file"`
+	Detail  string `json:"detail"` // Old title or file for renames, tags for updates
+}
+
+var scanHeader = []string{"action", "topic_id", "title", "file", "detail"}
+
+func (r scanRecord) values() []string {
+	return []string{r.Action, r.TopicID, r.Title, r.File, r.Detail}
+}
+
+// scanLog prints scan changes as they happen, or collects them as records
+// for --format output.
+type scanLog struct {
+	text    bool
+	records []scanRecord
+}
+
+func (l *scanLog) add(action string, topic *storage.Topic, detail, line string) {
+	if l.text {
+		fmt.Println(line)
+		return
+	}
+	l.records = append(l.records, scanRecord{
+		Action:  action,
+		TopicID: topic.ID,
+		Title:   topic.Title,
+		File:    topic.File,
+		Detail:  detail,
+	})
+}
+
+// printf prints text that only makes sense in table output.
+func (l *scanLog) printf(format string, args ...any) {
+	if l.text {
+		fmt.Printf(format, args...)
+	}
+}
 
-		if len(orphans) > 0 {
-			fmt.Printf("\nOrphaned topics (%d):\n", len(orphans))
-			for _, title := range orphans {
-				fmt.Printf("  ? %s\n", title)
+// matchTopics pairs each scanned topic with a tracked topic ID, or "" if
+// it is new. Matching is tried by frontmatter uid, then title, then file
+// and heading, then content similarity; each tracked topic is used once.
+func matchTopics(store storage.Storage, topics []parser.ParsedTopic, relPaths []string) []string {
+	matches := make([]string, len(topics))
+	claimed := make(map[string]bool)
+
+	claim := func(i int, topic *storage.Topic) {
+		if topic != nil && !claimed[topic.ID] {
+			matches[i] = topic.ID
+			claimed[topic.ID] = true
+		}
+	}
+
+	for i, t := range topics {
+		claim(i, store.GetTopicByUID(t.UID, t.Heading))
+	}
+	for i, t := range topics {
+		if matches[i] == "" {
+			claim(i, store.GetTopicByTitle(t.Title))
+		}
+	}
+
+	existing := store.GetAllTopics()
+	for i, t := range topics {
+		if matches[i] != "" {
+			continue
+		}
+		for j := range existing {
+			if existing[j].File == relPaths[i] && existing[j].Heading == t.Heading {
+				claim(i, &existing[j])
+				break
 			}
-			fmt.Println("\nUse 'recall remove <title>' to clean up.")
 		}
+	}
 
-		return nil
-	},
+	type candidate struct {
+		topic int
+		id    string
+		score float64
+	}
+	var candidates []candidate
+	for i, t := range topics {
+		if matches[i] != "" {
+			continue
+		}
+		for _, e := range existing {
+			if claimed[e.ID] {
+				continue
+			}
+			if score := parser.Similarity(t.Fingerprint, e.Fingerprint); score >= minRenameSimilarity {
+				candidates = append(candidates, candidate{topic: i, id: e.ID, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a].score > candidates[b].score
+	})
+	for _, c := range candidates {
+		if matches[c.topic] == "" && !claimed[c.id] {
+			matches[c.topic] = c.id
+			claimed[c.id] = true
+		}
+	}
+
+	return matches
+}
+
+// writeMissingUIDs adds a uid to the frontmatter of every scanned file that
+// lacks one and records it on the file's topics.
+func writeMissingUIDs(store storage.Storage, log *scanLog, topics []parser.ParsedTopic, relPaths []string) error {
+	uids := make(map[string]string)
+	for i, t := range topics {
+		if t.UID != "" {
+			continue
+		}
+
+		uid, ok := uids[t.File]
+		if !ok {
+			var err error
+			uid, err = newUID()
+			if err != nil {
+				return err
+			}
+			if err := parser.SetFrontmatterFields(t.File, []parser.Field{{Key: "uid", Value: uid}}); err != nil {
+				return err
+			}
+			uids[t.File] = uid
+		}
+
+		topic := store.GetTopicByTitle(t.Title)
+		if topic == nil {
+			continue
+		}
+		topic.UID = uid
+		if err := store.UpdateTopic(topic); err != nil {
+			return err
+		}
+		if !ok {
+			log.add("uid", topic, uid, fmt.Sprintf("  # %s: uid %s", relPaths[i], uid))
+		}
+	}
+	return nil
+}
+
+// newUID returns a random uid for a note's frontmatter.
+func newUID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating uid: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func toFlashcards(cards []parser.Flashcard) []storage.Flashcard {
+	result := make([]storage.Flashcard, 0, len(cards))
+	for _, c := range cards {
+		result = append(result, storage.Flashcard{
+			Kind:   c.Kind,
+			Prompt: c.Prompt,
+			Answer: c.Answer,
+		})
+	}
+	return result
 }
 
 func init() {
+	scanCmd.Flags().Bool("prune", false, "Stop tracking orphaned topics and delete their history")
+	scanCmd.Flags().Bool("archive", false, "Archive orphaned topics, keeping their history")
+	scanCmd.Flags().Bool("relink", false, "Interactively relink orphaned topics to new files")
+	scanCmd.MarkFlagsMutuallyExclusive("prune", "archive", "relink")
+	scanCmd.Flags().Bool("write-uid", false, "Write a uid into the frontmatter of tracked files that lack one")
 	rootCmd.AddCommand(scanCmd)
 }
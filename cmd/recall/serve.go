@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/markdown"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// maxHistoryListed caps the reviews on the history page.
+const maxHistoryListed = 200
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Review topics in a web browser",
+	Long: `Serve a web interface for reviewing topics, for those who prefer a
+browser to the terminal.
+
+Pages:
+  /              Topics due today and in the coming week
+  /topics/<id>   A topic's notes, rating buttons and review history
+  /history       Recent reads and reviews
+  /tags          Tags, and the topics for each tag
+
+The server reads and writes the same storage as the CLI, with the same
+locking, so recall commands can run while it is serving. Each page loads
+the latest data, and a rating made from a stale page is refused.
+
+There is no authentication: keep the default loopback address unless the
+network is trusted.
+
+Examples:
+  recall serve
+  recall serve --addr 127.0.0.1:9000`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+
+		wikiPath, err := getWikiPath()
+		if err != nil {
+			return err
+		}
+		srv, err := newServer(wikiPath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Serving recall on http://%s (Ctrl+C to stop)\n", addr)
+		server := &http.Server{
+			Addr:              addr,
+			Handler:           srv.routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		return server.ListenAndServe()
+	},
+}
+
+// server handles web requests. It opens the storage for every request, so
+// it always sees changes made by the CLI.
+type server struct {
+	wikiPath string
+	pages    map[string]*template.Template
+}
+
+func newServer(wikiPath string) (*server, error) {
+	s := &server{wikiPath: wikiPath, pages: make(map[string]*template.Template)}
+	funcs := template.FuncMap{"join": joinTags}
+	for _, page := range []string{"dashboard", "topic", "history", "tags", "tag", "error"} {
+		t, err := template.New("").Funcs(funcs).ParseFS(templateFS, "templates/layout.html", "templates/topics.html", "templates/reviews.html", "templates/"+page+".html")
+		if err != nil {
+			return nil, err
+		}
+		s.pages[page] = t
+	}
+	return s, nil
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handle(s.dashboard))
+	mux.HandleFunc("GET /topics/{id}", s.handle(s.topic))
+	mux.HandleFunc("POST /topics/{id}/rate", s.handle(s.rate))
+	mux.HandleFunc("GET /history", s.handle(s.history))
+	mux.HandleFunc("GET /tags", s.handle(s.tags))
+	mux.HandleFunc("GET /tags/{tag}", s.handle(s.tag))
+	return mux
+}
+
+// request is what page handlers get: the open storage and scheduling
+// settings, loaded fresh for each request.
+type request struct {
+	*http.Request
+	store storage.Storage
+	cfg   *config.Config
+	clock fsrs.Clock
+	now   time.Time
+}
+
+// httpError is an error with an HTTP status.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+// page is a rendered template name and its data, or a redirect.
+type page struct {
+	name     string
+	data     any
+	redirect string
+}
+
+func (s *server) handle(fn func(*request) (page, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := s.serve(r, fn)
+		if err != nil {
+			status := http.StatusInternalServerError
+			var herr *httpError
+			if errors.As(err, &herr) {
+				status = herr.status
+			}
+			w.WriteHeader(status)
+			s.render(w, page{name: "error", data: errorPage{Title: http.StatusText(status), Error: err.Error()}})
+			return
+		}
+		if p.redirect != "" {
+			http.Redirect(w, r, p.redirect, http.StatusSeeOther)
+			return
+		}
+		s.render(w, p)
+	}
+}
+
+func (s *server) serve(r *http.Request, fn func(*request) (page, error)) (page, error) {
+	if r.Method == http.MethodPost && !sameOrigin(r) {
+		return page{}, &httpError{http.StatusForbidden, errors.New("cross-site request refused")}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return page{}, err
+	}
+	clock, err := cfg.Clock()
+	if err != nil {
+		return page{}, err
+	}
+	store, err := getStorage()
+	if err != nil {
+		return page{}, err
+	}
+	defer store.Close()
+
+	return fn(&request{Request: r, store: store, cfg: cfg, clock: clock, now: time.Now()})
+}
+
+func (s *server) render(w http.ResponseWriter, p page) {
+	var buf bytes.Buffer
+	if err := s.pages[p.name].ExecuteTemplate(&buf, "layout", p.data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// sameOrigin reports whether a form was posted from this server's own
+// pages, so other sites cannot rate topics through the browser.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true // Not a browser, or an old one
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// topicRow is a topic in a page's topic list.
+type topicRow struct {
+	ID, Title string
+	Tags      []string
+	State     string
+	Due       string
+	DueClass  string // overdue, today or later
+	Action    string // Read or Review
+}
+
+func newTopicRow(t storage.Topic, clock fsrs.Clock, now time.Time) topicRow {
+	row := topicRow{ID: t.ID, Title: t.Title, Tags: t.Tags, State: stateName(t.Card.State), Action: "Review"}
+	if t.Card.State == fsrs.New {
+		row.Action = "Read"
+	}
+
+	days := clock.Days(now, t.DueAt())
+	switch {
+	case !t.IsActive():
+		row.Due, row.DueClass = t.Status, "later"
+	case t.Card.State == fsrs.New:
+		row.Due, row.DueClass = "new", "today"
+	case days < 0:
+		row.Due, row.DueClass = fmt.Sprintf("%d days overdue", -days), "overdue"
+	case days == 0:
+		row.Due, row.DueClass = "today", "today"
+	default:
+		row.Due, row.DueClass = fmt.Sprintf("in %d days", days), "later"
+	}
+	return row
+}
+
+type dashboardPage struct {
+	Title         string
+	Tag           string
+	Rated         string // Confirmation of the last rating
+	Today         []topicRow
+	Week          []topicRow
+	QueuedNew     int
+	QueuedReviews int
+	Done          int // Topics read or reviewed today
+}
+
+func (s *server) dashboard(r *request) (page, error) {
+	tag := r.URL.Query().Get("tag")
+	weekEnd := r.clock.EndOfDay(r.clock.AddDays(r.now, 7))
+	topics, allot := topicsDue(r.cfg, r.store, r.clock, tag, r.now, weekEnd)
+
+	p := dashboardPage{Title: "Due", Tag: tag, Rated: r.URL.Query().Get("rated"), QueuedNew: allot.QueuedNew, QueuedReviews: allot.QueuedReviews}
+	for i, t := range topics {
+		row := newTopicRow(t, r.clock, r.now)
+		if i < len(allot.Topics) {
+			p.Today = append(p.Today, row)
+		} else {
+			p.Week = append(p.Week, row)
+		}
+	}
+	read, reviewed := doneToday(r.store, r.clock, r.now)
+	p.Done = read + reviewed
+	return page{name: "dashboard", data: p}, nil
+}
+
+type topicPage struct {
+	Title    string
+	Topic    topicRow
+	File     string
+	Notes    template.HTML
+	NotesErr string
+	Ratings  []ratingButton
+	Version  string // Card.LastReview, to refuse ratings from stale pages
+	Shown    int64  // When the page was rendered, for the review duration
+	Tag      string
+	Rated    string // Confirmation of the previous rating
+	History  []historyRow
+	Reviewed bool // Rating is possible
+}
+
+type ratingButton struct {
+	Value int
+	Label string
+	Hint  string
+}
+
+func (s *server) topic(r *request) (page, error) {
+	t := r.store.GetTopic(r.PathValue("id"))
+	if t == nil {
+		return page{}, &httpError{http.StatusNotFound, fmt.Errorf("topic not found: %s", r.PathValue("id"))}
+	}
+
+	p := topicPage{
+		Title:    t.Title,
+		Topic:    newTopicRow(*t, r.clock, r.now),
+		File:     t.File,
+		Version:  t.Card.LastReview.Format(time.RFC3339Nano),
+		Shown:    r.now.UnixMilli(),
+		Tag:      r.URL.Query().Get("tag"),
+		Rated:    r.URL.Query().Get("rated"),
+		Reviewed: t.IsActive(),
+	}
+
+	notes, err := readTopicNotes(&topicLocation{File: filepath.Join(s.wikiPath, t.File), Heading: t.Heading})
+	if err != nil {
+		p.NotesErr = err.Error()
+	}
+	p.Notes = template.HTML(markdown.ToHTML(notes))
+
+	if t.Card.State == fsrs.New {
+		p.Ratings = []ratingButton{
+			{1, "Didn't understand", ""},
+			{2, "Partially", "Partially understood"},
+			{3, "Understood", "Understood well"},
+			{4, "Mastered", "Mastered it"},
+		}
+	} else {
+		p.Ratings = []ratingButton{
+			{1, "Again", "Forgot completely"},
+			{2, "Hard", "Difficult to recall"},
+			{3, "Good", "Recalled with effort"},
+			{4, "Easy", "Recalled effortlessly"},
+		}
+	}
+
+	for _, log := range r.store.GetReviewHistory(t.ID) {
+		if log.CardID == "" {
+			p.History = append(p.History, newHistoryRow(log, t.Title, r.clock))
+		}
+	}
+	return page{name: "topic", data: p}, nil
+}
+
+// rate records a rating for a topic, like 'recall session', and moves on
+// to the next due topic.
+func (s *server) rate(r *request) (page, error) {
+	id := r.PathValue("id")
+	rating, err := strconv.Atoi(r.FormValue("rating"))
+	if err != nil || rating < 1 || rating > 4 {
+		return page{}, &httpError{http.StatusBadRequest, fmt.Errorf("invalid rating: %s", r.FormValue("rating"))}
+	}
+	seen, err := time.Parse(time.RFC3339Nano, r.FormValue("version"))
+	if err != nil {
+		return page{}, &httpError{http.StatusBadRequest, fmt.Errorf("invalid version: %s", r.FormValue("version"))}
+	}
+	shown, _ := strconv.ParseInt(r.FormValue("shown"), 10, 64)
+	tag := r.FormValue("tag")
+
+	if r.store.GetTopic(id) == nil {
+		return page{}, &httpError{http.StatusNotFound, fmt.Errorf("topic not found: %s", id)}
+	}
+	var duration time.Duration
+	if shown > 0 {
+		duration = r.now.Sub(time.UnixMilli(shown))
+	}
+	_, after, err := rateTopic(r.cfg, r.store, id, seen, fsrs.Rating(rating), r.now, duration)
+	if errors.Is(err, errTopicChanged) {
+		return page{}, &httpError{http.StatusConflict, fmt.Errorf("%w; reload the page", err)}
+	}
+	if err != nil {
+		return page{}, err
+	}
+	topic := &after
+
+	query := url.Values{}
+	query.Set("rated", fmt.Sprintf("%s: next review %s", topic.Title, r.clock.StartOfDay(topic.Card.Due).Format("Jan 2, 2006")))
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	due, _ := topicsDue(r.cfg, r.store, r.clock, tag, r.now, r.clock.EndOfDay(r.now))
+	for _, t := range due {
+		if t.ID != topic.ID {
+			return page{redirect: "/topics/" + url.PathEscape(t.ID) + "?" + query.Encode()}, nil
+		}
+	}
+	return page{redirect: "/?" + query.Encode()}, nil
+}
+
+// historyRow is one read, review or reschedule.
+type historyRow struct {
+	TopicID, Topic string
+	Date           string
+	Kind           string
+	Rating         string
+	Recall         string
+	Interval       string
+}
+
+func newHistoryRow(log storage.ReviewLog, title string, clock fsrs.Clock) historyRow {
+	row := historyRow{
+		TopicID:  log.TopicID,
+		Topic:    title,
+		Date:     clock.In(log.ReviewedAt).Format("Jan 2, 2006 15:04"),
+		Interval: fmt.Sprintf("%dd", log.ScheduledDays),
+		Rating:   "-",
+		Recall:   "-",
+	}
+	switch log.Kind {
+	case storage.KindRead:
+		row.Kind = "First read"
+		row.Rating = understandingNames[log.Rating]
+	case storage.KindReschedule:
+		row.Kind = "Reschedule"
+	default:
+		row.Kind = "Review"
+		if log.Kind == storage.KindRelearn {
+			row.Kind = "Relearn"
+		}
+		row.Rating = ratingName(log.Rating)
+		row.Recall = fmt.Sprintf("%.0f%%", log.Retrievability*100)
+	}
+	return row
+}
+
+type historyPage struct {
+	Title   string
+	Reviews []historyRow
+	Total   int
+}
+
+func (s *server) history(r *request) (page, error) {
+	titles := make(map[string]string)
+	for _, t := range r.store.GetAllTopics() {
+		titles[t.ID] = t.Title
+	}
+
+	var logs []storage.ReviewLog
+	for _, log := range r.store.GetAllReviews() {
+		if log.CardID == "" {
+			logs = append(logs, log)
+		}
+	}
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].ReviewedAt.After(logs[j].ReviewedAt)
+	})
+
+	p := historyPage{Title: "History", Total: len(logs)}
+	for i, log := range logs {
+		if i == maxHistoryListed {
+			break
+		}
+		p.Reviews = append(p.Reviews, newHistoryRow(log, titles[log.TopicID], r.clock))
+	}
+	return page{name: "history", data: p}, nil
+}
+
+type tagRow struct {
+	Name   string
+	Topics int
+	Due    int
+}
+
+type tagsPage struct {
+	Title string
+	Tags  []tagRow
+}
+
+func (s *server) tags(r *request) (page, error) {
+	due := make(map[string]int)
+	for _, t := range r.store.GetDueTopics(r.clock.EndOfDay(r.now)) {
+		for _, tag := range t.Tags {
+			due[tag]++
+		}
+	}
+
+	p := tagsPage{Title: "Tags"}
+	for name, count := range r.store.GetAllTags() {
+		p.Tags = append(p.Tags, tagRow{Name: name, Topics: count, Due: due[name]})
+	}
+	sort.Slice(p.Tags, func(i, j int) bool { return p.Tags[i].Name < p.Tags[j].Name })
+	return page{name: "tags", data: p}, nil
+}
+
+type tagPage struct {
+	Title  string
+	Tag    string
+	Topics []topicRow
+}
+
+func (s *server) tag(r *request) (page, error) {
+	name := r.PathValue("tag")
+	topics := r.store.GetTopicsByTag(name)
+	if len(topics) == 0 {
+		return page{}, &httpError{http.StatusNotFound, fmt.Errorf("tag not found: %s", name)}
+	}
+	sortDueTopics(topics, r.clock, r.now)
+
+	p := tagPage{Title: "#" + name, Tag: name}
+	for _, t := range topics {
+		if t.Status != storage.StatusArchived {
+			p.Topics = append(p.Topics, newTopicRow(t, r.clock, r.now))
+		}
+	}
+	return page{name: "tag", data: p}, nil
+}
+
+type errorPage struct {
+	Title string
+	Error string
+}
+
+func joinTags(tags []string) string {
+	return strings.Join(tags, ", ")
+}
+
+func init() {
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Review every due topic in one interactive session",
+	Long: `Walk through all topics due today, one after another.
+
+For each topic the notes are printed and you are asked for a rating.
+New topics are offered as a first read, everything else as a review.
+Topics are presented in the same order as 'recall due' (overdue first,
+new topics last) and respect the new_per_day and reviews_per_day limits.
+
+Keys:
+  1-4 - Rate the topic and move on
+  s   - Skip the topic
+  u   - Undo the previous rating
+  q   - Quit the session
+
+Example:
+  recall session
+  recall session --tag k8s`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wikiPath, err := getWikiPath()
+		if err != nil {
+			return err
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		tag, _ := cmd.Flags().GetString("tag")
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		topics, allot := topicsDue(cfg, store, clock, tag, now, clock.EndOfDay(now))
+		if len(topics) == 0 {
+			fmt.Println("No topics due for review!")
+			if allot.QueuedNew > 0 || allot.QueuedReviews > 0 {
+				fmt.Printf("Daily limit reached: %d more new and %d more reviews queued\n",
+					allot.QueuedNew, allot.QueuedReviews)
+			}
+			return nil
+		}
+
+		s := &session{
+			store:    store,
+			cfg:      cfg,
+			wikiPath: wikiPath,
+			in:       bufio.NewReader(os.Stdin),
+			skipped:  make(map[int]bool),
+		}
+		if err := s.run(topics); err != nil {
+			return err
+		}
+
+		s.printSummary(len(topics))
+		return nil
+	},
+}
+
+// sessionStep records a rated topic so it can be undone.
+type sessionStep struct {
+	index  int
+	before storage.Topic
+	after  fsrs.Card
+	rating fsrs.Rating
+}
+
+type session struct {
+	store    storage.Storage
+	cfg      *config.Config
+	wikiPath string
+	in       *bufio.Reader
+
+	steps   []sessionStep
+	skipped map[int]bool
+}
+
+func (s *session) run(topics []storage.Topic) error {
+	for i := 0; i < len(topics); {
+		topic := s.store.GetTopic(topics[i].ID)
+		if topic == nil {
+			i++
+			continue
+		}
+
+		isNew := topic.Card.State == fsrs.New
+		s.showTopic(topic, i+1, len(topics), isNew)
+		shown := time.Now()
+
+		if isNew {
+			printUnderstandingOptions()
+			fmt.Print("\nUnderstanding [1-4, s=skip, u=undo, q=quit]: ")
+		} else {
+			printRatingOptions()
+			fmt.Print("\nRating [1-4, s=skip, u=undo, q=quit]: ")
+		}
+
+		input, err := s.in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		input = strings.ToLower(strings.TrimSpace(input))
+		if err == io.EOF && input == "" {
+			return nil
+		}
+
+		switch input {
+		case "q":
+			return nil
+		case "s":
+			s.skipped[i] = true
+			i++
+		case "u":
+			prev, ok, err := s.undo()
+			if errors.Is(err, errTopicChanged) {
+				fmt.Printf("\nCannot undo: %v\n", err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("\nNothing to undo.")
+				continue
+			}
+			fmt.Printf("\nUndid rating for: %s\n", prev.before.Title)
+			i = prev.index
+		case "1", "2", "3", "4":
+			rating := fsrs.Rating(input[0] - '0')
+			if err := s.rate(topic, rating, i, time.Since(shown)); err != nil {
+				return err
+			}
+			i++
+		default:
+			fmt.Printf("\nInvalid input: %s\n", input)
+		}
+	}
+	return nil
+}
+
+func (s *session) showTopic(topic *storage.Topic, pos, total int, isNew bool) {
+	action := "Reviewing"
+	if isNew {
+		action = "First read"
+	}
+	fmt.Printf("\n[%d/%d] %s: %s\n\n", pos, total, action, topic.Title)
+
+	notes, err := readTopicNotes(&topicLocation{
+		File:    filepath.Join(s.wikiPath, topic.File),
+		Heading: topic.Heading,
+	})
+	switch {
+	case err != nil:
+		fmt.Printf("(could not read notes: %v)\n\n", err)
+	case notes == "":
+		fmt.Print("(no notes)\n\n")
+	default:
+		fmt.Printf("%s\n\n", notes)
+	}
+}
+
+func (s *session) rate(topic *storage.Topic, rating fsrs.Rating, index int, duration time.Duration) error {
+	before, after, err := rateTopic(s.cfg, s.store, topic.ID, topic.Card.LastReview, rating, time.Now(), duration)
+	if errors.Is(err, errTopicChanged) {
+		fmt.Printf("\n%v, skipping\n", err)
+		s.skipped[index] = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	delete(s.skipped, index)
+	s.steps = append(s.steps, sessionStep{index: index, before: before, after: after.Card, rating: rating})
+	fmt.Printf("\nNext review: %s\n", after.Card.Due.Format("Jan 2, 2006"))
+	return nil
+}
+
+// undo restores the card of the most recently rated topic and drops its
+// review log entry. It is refused if the topic was changed elsewhere
+// since.
+func (s *session) undo() (sessionStep, bool, error) {
+	if len(s.steps) == 0 {
+		return sessionStep{}, false, nil
+	}
+
+	last := s.steps[len(s.steps)-1]
+	s.steps = s.steps[:len(s.steps)-1]
+
+	var restored storage.Topic
+	err := s.store.Update(func() error {
+		topic := s.store.GetTopic(last.before.ID)
+		if topic == nil {
+			return fmt.Errorf("topic not found: %s", last.before.ID)
+		}
+		if !topic.Card.LastReview.Equal(last.after.LastReview) {
+			return fmt.Errorf("%s %w: it was rated elsewhere", topic.Title, errTopicChanged)
+		}
+		topic.Card = last.before.Card
+		if err := s.store.UpdateTopic(topic); err != nil {
+			return err
+		}
+		restored = *topic
+		return s.store.RemoveLastReview(topic.ID, "")
+	})
+	if err != nil {
+		return last, false, err
+	}
+	if err := syncFrontmatter(restored); err != nil {
+		return last, false, err
+	}
+
+	return last, true, nil
+}
+
+func (s *session) printSummary(total int) {
+	read, reviewed := 0, 0
+	ratings := make(map[fsrs.Rating]int)
+	for _, step := range s.steps {
+		if step.before.Card.State == fsrs.New {
+			read++
+		} else {
+			reviewed++
+		}
+		ratings[step.rating]++
+	}
+
+	remaining := total - len(s.steps) - len(s.skipped)
+
+	fmt.Println("\nSession complete!")
+	fmt.Printf("Read: %d | Reviewed: %d | Skipped: %d | Remaining: %d\n",
+		read, reviewed, len(s.skipped), remaining)
+	if len(s.steps) > 0 {
+		fmt.Printf("Again: %d | Hard: %d | Good: %d | Easy: %d\n",
+			ratings[fsrs.Again], ratings[fsrs.Hard], ratings[fsrs.Good], ratings[fsrs.Easy])
+	}
+}
+
+func filterByTag(topics []storage.Topic, tag string) []storage.Topic {
+	if tag == "" {
+		return topics
+	}
+
+	var filtered []storage.Topic
+	for _, t := range topics {
+		if slices.Contains(t.Tags, tag) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+func init() {
+	sessionCmd.Flags().String("tag", "", "Only review topics with this tag")
+	rootCmd.AddCommand(sessionCmd)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/stats"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// maxLapseLeaders is how many topics the lapse table shows.
+	maxLapseLeaders = 5
+	// barWidth is the width of a full histogram bar.
+	barWidth = 20
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show retention, streaks and card statistics",
+	Long: `Summarize how your learning is going:
+
+  - True retention (reviews not rated Again) per month and per tag. First
+    reads and a flashcard's first review are not counted.
+  - Reviews per day as a sparkline, and your current and longest streak
+  - Card states (New/Learning/Review/Relearn) for topics and flashcards
+  - Mature (interval of 21+ days) vs young topics, average stability and
+    difficulty
+  - The topics you forget most often
+
+With --format, every figure is one metric/key/value row, e.g.
+"retention,2026-03,0.91" for a month's retention rate.
+
+Examples:
+  recall stats
+  recall stats --days 90   # Sparkline over the last 90 days
+  recall stats --format csv`,
+	Annotations: supportsFormat,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 1 {
+			return fmt.Errorf("invalid days: %d", days)
+		}
+
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		data, err := store.Export()
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		s := stats.Compute(data, clock, now, days, maxLapseLeaders)
+		if outputFormat(cmd) != formatTable {
+			return printRecords(cmd, statsHeader, statsRecords(s, clock, now))
+		}
+
+		total := 0
+		for _, n := range s.DailyReviews {
+			total += n
+		}
+		fmt.Printf("Retention: %s (%d reviews) | Streak: %d days (longest %d)\n",
+			formatRate(s.Overall), s.Overall.Reviews, s.CurrentStreak, s.LongestStreak)
+		fmt.Printf("Mature: %d | Young: %d | Avg stability: %.1f days | Avg difficulty: %.2f\n",
+			s.Mature, s.Young, s.AvgStability, s.AvgDifficulty)
+
+		fmt.Printf("\nReviews, last %d days (%d total, %.1f/day):\n", days, total, float64(total)/float64(days))
+		fmt.Printf("  %s\n", sparkline(s.DailyReviews))
+
+		if len(s.ByMonth) > 0 {
+			fmt.Println("\nRetention by month:")
+			renderRetention("Month", s.ByMonth)
+		}
+		if len(s.ByTag) > 0 {
+			fmt.Println("\nRetention by tag:")
+			renderRetention("Tag", s.ByTag)
+		}
+
+		fmt.Println("\nCard states:")
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header("State", "Topics", "Flashcards")
+		for _, state := range []fsrs.State{fsrs.New, fsrs.Learning, fsrs.Review, fsrs.Relearn} {
+			table.Append(stateName(state), fmt.Sprint(s.TopicStates[state]), fmt.Sprint(s.CardStates[state]))
+		}
+		table.Render()
+
+		if len(s.LapseLeaders) > 0 {
+			fmt.Println("\nMost lapses:")
+			table := tablewriter.NewWriter(os.Stdout)
+			table.Header("Title", "Lapses", "Stability")
+			for _, t := range s.LapseLeaders {
+				table.Append(truncateText(t.Title, maxTitleWidth), fmt.Sprint(t.Card.Lapses),
+					fmt.Sprintf("%.1f days", t.Card.Stability))
+			}
+			table.Render()
+		}
+
+		return nil
+	},
+}
+
+// statsRecord is the schema for one figure of the statistics. Key tells
+// apart figures of the same metric: a month, a #tag, a day, a state or a
+// title. It is empty for overall figures.
+type statsRecord struct {
+	Metric string  `json:"metric"`
+	Key    string  `json:"key"`
+	Value  float64 `json:"value"`
+}
+
+var statsHeader = []string{"metric", "key", "value"}
+
+func (r statsRecord) values() []string {
+	return []string{r.Metric, r.Key, strconv.FormatFloat(r.Value, 'f', -1, 64)}
+}
+
+// statsRecords flattens statistics into records. Retention rates are
+// fractions; months and days are formatted as 2006-01 and 2006-01-02.
+func statsRecords(s *stats.Stats, clock fsrs.Clock, now time.Time) []statsRecord {
+	var records []statsRecord
+	add := func(metric, key string, value float64) {
+		records = append(records, statsRecord{Metric: metric, Key: key, Value: value})
+	}
+	retention := func(key string, r stats.Retention) {
+		add("reviews", key, float64(r.Reviews))
+		if r.Reviews > 0 {
+			add("retention", key, r.Rate())
+		}
+	}
+
+	retention("", s.Overall)
+	for _, r := range s.ByMonth {
+		retention(r.Key, r)
+	}
+	for _, r := range s.ByTag {
+		retention("#"+r.Key, r)
+	}
+
+	add("current_streak", "", float64(s.CurrentStreak))
+	add("longest_streak", "", float64(s.LongestStreak))
+	add("mature", "", float64(s.Mature))
+	add("young", "", float64(s.Young))
+	add("avg_stability", "", s.AvgStability)
+	add("avg_difficulty", "", s.AvgDifficulty)
+
+	for i, n := range s.DailyReviews {
+		day := clock.AddDays(now, i-len(s.DailyReviews)+1)
+		add("daily_reviews", clock.Date(day), float64(n))
+	}
+	for _, state := range []fsrs.State{fsrs.New, fsrs.Learning, fsrs.Review, fsrs.Relearn} {
+		add("topic_state", stateName(state), float64(s.TopicStates[state]))
+		add("flashcard_state", stateName(state), float64(s.CardStates[state]))
+	}
+	for _, t := range s.LapseLeaders {
+		add("lapses", t.Title, float64(t.Card.Lapses))
+	}
+	return records
+}
+
+func renderRetention(label string, rows []stats.Retention) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.Header(label, "Reviews", "Retention", "")
+	for _, r := range rows {
+		table.Append(r.Key, fmt.Sprint(r.Reviews), formatRate(r), bar(r.Rate(), barWidth))
+	}
+	table.Render()
+}
+
+func formatRate(r stats.Retention) string {
+	if r.Reviews == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", r.Rate()*100)
+}
+
+var sparkRunes = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws one bar per value, scaled to the largest. Zero is always
+// the lowest bar so that any activity stands out.
+func sparkline(values []int) string {
+	peak := 0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if v > 0 {
+			level = 1 + int(math.Round(float64(v)/float64(peak)*float64(len(sparkRunes)-2)))
+		}
+		b.WriteRune(sparkRunes[level])
+	}
+	return b.String()
+}
+
+// bar draws a horizontal bar for a fraction between 0 and 1.
+func bar(fraction float64, width int) string {
+	n := int(math.Round(fraction * float64(width)))
+	return strings.Repeat("█", n) + strings.Repeat("░", width-n)
+}
+
+func init() {
+	statsCmd.Flags().Int("days", 30, "Number of days in the reviews-per-day sparkline")
+	rootCmd.AddCommand(statsCmd)
+}
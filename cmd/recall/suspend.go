@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var suspendCmd = &cobra.Command{
+	Use:   "suspend <topic-title>",
+	Short: "Stop reviewing a topic until it is unsuspended",
+	Long: `Suspend a topic so it no longer shows up in 'recall due' or sessions.
+
+Unlike 'recall remove', the topic keeps its schedule and review history.
+You can also suspend a topic from its note with 'review: suspended'.
+
+Example:
+  recall suspend "Docker Networking"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTopicTitles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, topic, err := getTopicForStatus(args[0])
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		if topic.Status == storage.StatusSuspended {
+			fmt.Printf("Already suspended: %s\n", topic.Title)
+			return nil
+		}
+
+		topic, err = updateTopic(store, topic.ID, func(t *storage.Topic) error {
+			t.Status = storage.StatusSuspended
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Suspended: %s\n", topic.Title)
+		return nil
+	},
+}
+
+var unsuspendCmd = &cobra.Command{
+	Use:   "unsuspend <topic-title>",
+	Short: "Resume reviewing a suspended or buried topic",
+	Long: `Resume a suspended or buried topic. It becomes due again on its
+original schedule, so it may show up as overdue right away.
+
+Example:
+  recall unsuspend "Docker Networking"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTopicTitles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, topic, err := getTopicForStatus(args[0])
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		if topic.Status != storage.StatusSuspended && !topic.IsBuried(time.Now()) {
+			fmt.Printf("Not suspended: %s\n", topic.Title)
+			return nil
+		}
+
+		var noteSuspended bool
+		topic, err = updateTopic(store, topic.ID, func(t *storage.Topic) error {
+			noteSuspended = t.NoteSuspended
+			t.Status = storage.StatusActive
+			t.NoteSuspended = false
+			t.BuriedUntil = time.Time{}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Unsuspended: %s\n", topic.Title)
+		if noteSuspended {
+			fmt.Println("Note: the topic's frontmatter still says 'review: suspended'; change it or the next scan will suspend it again.")
+		}
+		return nil
+	},
+}
+
+var buryCmd = &cobra.Command{
+	Use:   "bury <topic-title>",
+	Short: "Hide a topic for a few days",
+	Long: `Bury a topic so it is hidden until the start of the day N days from now.
+
+Its schedule is not changed; if it would be due while buried, it shows up
+when the bury ends. Use 'recall unsuspend' to dig it up early.
+
+Examples:
+  recall bury "Docker Networking"           # Hide until tomorrow
+  recall bury "Docker Networking" --days 3  # Hide for three days`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTopicTitles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		days, _ := cmd.Flags().GetInt("days")
+		if days < 1 {
+			return fmt.Errorf("invalid days: %d", days)
+		}
+
+		store, topic, err := getTopicForStatus(args[0])
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		clock, err := cfg.Clock()
+		if err != nil {
+			return err
+		}
+
+		topic, err = updateTopic(store, topic.ID, func(t *storage.Topic) error {
+			t.BuriedUntil = clock.AddDays(time.Now(), days)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Buried until %s: %s\n", topic.BuriedUntil.Format("Jan 2, 2006"), topic.Title)
+		return nil
+	},
+}
+
+func getTopicForStatus(title string) (storage.Storage, *storage.Topic, error) {
+	store, err := getStorage()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	topic := store.GetTopicByTitle(title)
+	if topic == nil {
+		return nil, nil, fmt.Errorf("topic not found: %s", title)
+	}
+	if topic.Status == storage.StatusArchived {
+		return nil, nil, fmt.Errorf("topic is archived: %s", title)
+	}
+
+	return store, topic, nil
+}
+
+func init() {
+	buryCmd.Flags().Int("days", 1, "Number of days to bury the topic for")
+	rootCmd.AddCommand(suspendCmd, unsuspendCmd, buryCmd)
+}
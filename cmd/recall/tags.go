@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/spf13/cobra"
 )
@@ -20,14 +21,28 @@ Example:
 Output:
   #devops (5)
   #algorithms (3)
-  #k8s (2)`,
+  #k8s (2)
+
+Use --format json, csv or tsv for a sorted list of {tag, topics}.`,
+	Annotations: supportsFormat,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		store, err := getStorage()
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
 		tags := store.GetAllTags()
+		if outputFormat(cmd) != formatTable {
+			records := make([]tagRecord, 0, len(tags))
+			for tag, count := range tags {
+				records = append(records, tagRecord{Tag: tag, Topics: count})
+			}
+			sort.Slice(records, func(i, j int) bool {
+				return records[i].Tag < records[j].Tag
+			})
+			return printRecords(cmd, []string{"tag", "topics"}, records)
+		}
 		if len(tags) == 0 {
 			fmt.Println("No tags found.")
 			return nil
@@ -42,6 +57,15 @@ Output:
 	},
 }
 
+type tagRecord struct {
+	Tag    string `json:"tag"`
+	Topics int    `json:"topics"`
+}
+
+func (r tagRecord) values() []string {
+	return []string{r.Tag, fmt.Sprint(r.Topics)}
+}
+
 func init() {
 	rootCmd.AddCommand(tagsCmd)
 }
@@ -3,11 +3,14 @@ package main
 import (
 	"fmt"
 	"io/fs"
+	"math/rand/v2"
 	"path/filepath"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/amiraminb/recall/internal/config"
+	"github.com/amiraminb/recall/internal/fsrs"
 	"github.com/amiraminb/recall/internal/storage"
 )
 
@@ -22,12 +25,72 @@ func getWikiPath() (string, error) {
 	return path, nil
 }
 
-func getStorage() (*storage.Storage, error) {
+func getStorage() (storage.Storage, error) {
 	wikiPath, err := getWikiPath()
 	if err != nil {
 		return nil, err
 	}
-	return storage.NewStorage(wikiPath)
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var backend string
+	if cfg != nil {
+		backend = cfg.Backend
+	}
+	return storage.Open(wikiPath, backend)
+}
+
+// reviewScheduler is schedulerFor with fuzz and load balancing applied
+// when enabled, for scheduling actual reviews.
+func reviewScheduler(cfg *config.Config, store storage.Storage, tags []string) (*fsrs.FSRS, error) {
+	scheduler, err := schedulerFor(cfg, tags)
+	if err != nil || cfg == nil {
+		return scheduler, err
+	}
+
+	if cfg.Fuzz {
+		scheduler.Rand = rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+	}
+	if cfg.LoadBalance {
+		scheduler.Load = dueLoad(store, scheduler.Clock)
+	}
+	return scheduler, nil
+}
+
+// dueLoad returns a function counting the topics and flashcards due on a
+// day. Counts are taken on first use.
+func dueLoad(store storage.Storage, clock fsrs.Clock) func(time.Time) int {
+	var counts map[string]int
+	return func(day time.Time) int {
+		if counts == nil {
+			counts = make(map[string]int)
+			for _, t := range store.GetAllTopics() {
+				if !t.IsActive() || t.Card.State == fsrs.New {
+					continue
+				}
+				counts[clock.Date(t.DueAt())]++
+				for _, c := range store.GetFlashcards(t.ID) {
+					counts[clock.Date(c.Card.Due)]++
+				}
+			}
+		}
+		return counts[clock.Date(day)]
+	}
+}
+
+func schedulerFor(cfg *config.Config, tags []string) (*fsrs.FSRS, error) {
+	params, err := cfg.SchedulerParams(tags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler config: %w", err)
+	}
+	clock, err := cfg.Clock()
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler config: %w", err)
+	}
+	scheduler := fsrs.NewSchedulerWithParams(params)
+	scheduler.Clock = clock
+	return scheduler, nil
 }
 
 func listWikiTitles(wikiPath string) ([]string, error) {
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo [topic-title]",
+	Short: "Undo the last review, read or reschedule",
+	Long: `Undo the most recent rating: the topic (or flashcard) gets back the
+schedule it had before, and the review is removed from its history.
+Changes made by 'recall reschedule' are undone one card at a time.
+
+Without a title, the last rating of any topic or flashcard is undone. With
+a title, the last rating of that topic or one of its flashcards. "Last"
+goes by review time, so history brought in by 'recall import anki' is
+only undone once every newer rating is. Run it again to step further back.
+
+Reviews recorded before undo support was added cannot be undone.
+
+Examples:
+  recall undo
+  recall undo "Docker Networking"`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTopicTitles,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := getStorage()
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+
+		var topicID string
+		if len(args) == 1 {
+			topic := store.GetTopicByTitle(args[0])
+			if topic == nil {
+				return fmt.Errorf("topic not found: %s", args[0])
+			}
+			topicID = topic.ID
+		}
+
+		var last *storage.ReviewLog
+		var title string
+		var card fsrs.Card
+		err = store.Update(func() error {
+			last = store.GetLastReview(topicID)
+			if last == nil {
+				return nil
+			}
+			if last.Before == nil {
+				return fmt.Errorf("cannot undo the review from %s: it predates undo support",
+					last.ReviewedAt.Format("Jan 2, 2006"))
+			}
+
+			topic := store.GetTopic(last.TopicID)
+			if topic == nil {
+				return fmt.Errorf("topic not found: %s", last.TopicID)
+			}
+			title = topic.Title
+			card = *last.Before
+
+			if last.CardID == "" {
+				topic.Card = card
+				if err := store.UpdateTopic(topic); err != nil {
+					return err
+				}
+			} else {
+				fc := store.GetFlashcard(last.CardID)
+				if fc == nil {
+					return fmt.Errorf("flashcard not found: %s", last.CardID)
+				}
+				title = fmt.Sprintf("%s (Q: %s)", topic.Title, fc.Prompt)
+				fc.Card = card
+				if err := store.UpdateFlashcard(fc); err != nil {
+					return err
+				}
+			}
+			return store.RemoveLastReview(last.TopicID, last.CardID)
+		})
+		if err != nil {
+			return err
+		}
+
+		if last == nil {
+			fmt.Println("Nothing to undo.")
+			return nil
+		}
+		if t := store.GetTopic(last.TopicID); last.CardID == "" && t != nil {
+			if err := syncFrontmatter(*t); err != nil {
+				return err
+			}
+		}
+
+		if last.Kind == storage.KindReschedule {
+			fmt.Printf("Undid reschedule of: %s\n", title)
+			fmt.Printf("Due again: %s\n", card.Due.Format("Jan 2, 2006"))
+			return nil
+		}
+		if last.CardID == "" && card.State == fsrs.New {
+			fmt.Printf("Undid first read of: %s\n", title)
+			return nil
+		}
+		fmt.Printf("Undid %s review of: %s\n", ratingName(last.Rating), title)
+		fmt.Printf("Due again: %s\n", card.Due.Format("Jan 2, 2006"))
+		return nil
+	},
+}
+
+func ratingName(r fsrs.Rating) string {
+	switch r {
+	case fsrs.Again:
+		return "Again"
+	case fsrs.Hard:
+		return "Hard"
+	case fsrs.Good:
+		return "Good"
+	case fsrs.Easy:
+		return "Easy"
+	}
+	return fmt.Sprint(int(r))
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
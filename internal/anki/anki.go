@@ -0,0 +1,379 @@
+// Package anki reads and writes Anki collections, either bare
+// (collection.anki2) or inside an .apkg package.
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	_ "modernc.org/sqlite"
+)
+
+// Revlog entry types.
+const (
+	ReviewLearn       = 0
+	ReviewReview      = 1
+	ReviewRelearn     = 2
+	ReviewFiltered    = 3 // Reviewed in a filtered deck
+	ReviewManual      = 4 // Rescheduled by hand, not a rating
+	ReviewRescheduled = 5 // Rescheduled by the FSRS helper, not a rating
+)
+
+// Collection is the part of an Anki collection recall cares about.
+type Collection struct {
+	SchedVer int // Scheduler version: 1 had three buttons while learning
+	Notes    []Note
+	Cards    []Card
+	Reviews  []Review // Oldest first
+
+	// Only used by Write.
+	Deck     string    // Name of the deck all cards go to
+	Created  time.Time // Start of the day card due days count from
+	DayStart int       // Hour the next day starts at
+}
+
+// Note is an Anki note with its fields in note type order.
+type Note struct {
+	ID     int64
+	GUID   string
+	Model  string // Note type name
+	Fields []Field
+	Tags   []string
+}
+
+type Field struct {
+	Name  string
+	Value string // HTML, as stored by Anki
+}
+
+// Card is one card generated from a note. Scheduling fields are only
+// used by Write.
+type Card struct {
+	ID     int64
+	NoteID int64
+	Ord    int // Template index
+
+	Type     int // CardNew, CardLearn, CardReview or CardRelearn
+	Queue    int // QueueSuspended or, for day-based queues, the same as Type
+	Due      int // Days since Created; position for new cards
+	Interval int // Days
+	Reps     int
+	Lapses   int
+	Data     string // JSON with the FSRS memory state
+}
+
+// Card types and queues.
+const (
+	CardNew     = 0
+	CardLearn   = 1
+	CardReview  = 2
+	CardRelearn = 3
+
+	QueueSuspended = -1
+	QueueDayLearn  = 3 // Learning card due on a day rather than a time
+)
+
+// Review is one revlog entry.
+type Review struct {
+	CardID       int64
+	Time         time.Time
+	Ease         int // Button pressed, 0 for manual changes
+	Type         int
+	Duration     time.Duration
+	Interval     int // Days; only used by Write
+	LastInterval int
+}
+
+// Field returns the value of the named field, ignoring case.
+func (n Note) Field(name string) (string, bool) {
+	for _, f := range n.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Rating converts the button pressed in a review to an FSRS rating. It
+// reports false for entries that are not ratings, like manual
+// rescheduling.
+func (c *Collection) Rating(r Review) (fsrs.Rating, bool) {
+	if r.Ease < 1 || r.Ease > 4 || r.Type == ReviewManual || r.Type == ReviewRescheduled {
+		return 0, false
+	}
+	// The v1 scheduler showed Again, Good and Easy while learning.
+	if c.SchedVer < 2 && (r.Type == ReviewLearn || r.Type == ReviewRelearn) && r.Ease > 1 {
+		if r.Ease == 4 {
+			return 0, false
+		}
+		return fsrs.Rating(r.Ease + 1), true
+	}
+	return fsrs.Rating(r.Ease), true
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</?(div|p|li)[^>]*>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// PlainText strips HTML from a field value and collapses whitespace.
+func PlainText(value string) string {
+	value = htmlBreak.ReplaceAllString(value, " ")
+	value = htmlTag.ReplaceAllString(value, "")
+	return strings.Join(strings.Fields(html.UnescapeString(value)), " ")
+}
+
+// Open reads a collection from an .apkg package or a collection file.
+//
+// Packages exported by Anki 2.1.50+ keep the collection in a compressed
+// format (collection.anki21b) that is not supported; export them with
+// "Support older Anki versions" enabled.
+func Open(path string) (*Collection, error) {
+	dbPath, cleanup, err := extractCollection(path)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	c, err := readCollection(db)
+	if err != nil {
+		return nil, fmt.Errorf("reading Anki collection: %w", err)
+	}
+	return c, nil
+}
+
+// extractCollection returns the path of the collection database, copied
+// to a temporary file when it is inside a package.
+func extractCollection(path string) (string, func(), error) {
+	noop := func() {}
+
+	zr, err := zip.OpenReader(path)
+	if errors.Is(err, zip.ErrFormat) {
+		return path, noop, nil // A bare collection file
+	}
+	if err != nil {
+		return "", noop, err
+	}
+	defer zr.Close()
+
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	// collection.anki2 next to an .anki21b is only a stub telling old
+	// Anki versions to upgrade.
+	entry := files["collection.anki21"]
+	if entry == nil && files["collection.anki21b"] == nil {
+		entry = files["collection.anki2"]
+	}
+	if entry == nil {
+		if files["collection.anki21b"] != nil {
+			return "", noop, fmt.Errorf("%s uses the compressed Anki 2.1.50+ format; export it again with \"Support older Anki versions\" enabled", path)
+		}
+		return "", noop, fmt.Errorf("no Anki collection in %s", path)
+	}
+
+	src, err := entry.Open()
+	if err != nil {
+		return "", noop, err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp("", "recall-anki-*.db")
+	if err != nil {
+		return "", noop, err
+	}
+	cleanup := func() { os.Remove(tmp.Name()) }
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		cleanup()
+		return "", noop, err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return "", noop, err
+	}
+	return tmp.Name(), cleanup, nil
+}
+
+// noteType is a note type as stored in col.models.
+type noteType struct {
+	Name   string `json:"name"`
+	Fields []struct {
+		Name string `json:"name"`
+		Ord  int    `json:"ord"`
+	} `json:"flds"`
+}
+
+func readCollection(db *sql.DB) (*Collection, error) {
+	var conf, models string
+	if err := db.QueryRow(`SELECT conf, models FROM col`).Scan(&conf, &models); err != nil {
+		return nil, err
+	}
+
+	c := &Collection{SchedVer: 1}
+	var settings struct {
+		SchedVer int `json:"schedVer"`
+	}
+	if json.Unmarshal([]byte(conf), &settings) == nil && settings.SchedVer > 0 {
+		c.SchedVer = settings.SchedVer
+	}
+
+	types := make(map[int64]noteType)
+	var byID map[string]noteType
+	if json.Unmarshal([]byte(models), &byID) == nil && len(byID) > 0 {
+		for id, t := range byID {
+			n, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				continue
+			}
+			types[n] = t
+		}
+	} else {
+		// Newer schemas keep note types in their own tables, and only
+		// ever use the v2 or v3 scheduler.
+		c.SchedVer = 2
+		var err error
+		if types, err = readNoteTypes(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := readNotes(db, c, types); err != nil {
+		return nil, err
+	}
+	if err := readCards(db, c); err != nil {
+		return nil, err
+	}
+	if err := readReviews(db, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readNoteTypes(db *sql.DB) (map[int64]noteType, error) {
+	types := make(map[int64]noteType)
+	rows, err := db.Query(`SELECT id, name FROM notetypes`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var t noteType
+		if err := rows.Scan(&id, &t.Name); err != nil {
+			return nil, err
+		}
+		types[id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fields, err := db.Query(`SELECT ntid, ord, name FROM fields ORDER BY ntid, ord`)
+	if err != nil {
+		return nil, err
+	}
+	defer fields.Close()
+	for fields.Next() {
+		var id int64
+		var f struct {
+			Name string `json:"name"`
+			Ord  int    `json:"ord"`
+		}
+		if err := fields.Scan(&id, &f.Ord, &f.Name); err != nil {
+			return nil, err
+		}
+		t := types[id]
+		t.Fields = append(t.Fields, f)
+		types[id] = t
+	}
+	return types, fields.Err()
+}
+
+func readNotes(db *sql.DB, c *Collection, types map[int64]noteType) error {
+	rows, err := db.Query(`SELECT id, mid, tags, flds FROM notes ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var n Note
+		var mid int64
+		var tags, flds string
+		if err := rows.Scan(&n.ID, &mid, &tags, &flds); err != nil {
+			return err
+		}
+		t := types[mid]
+		n.Model = t.Name
+		n.Tags = strings.Fields(tags)
+		for i, value := range strings.Split(flds, "\x1f") {
+			name := fmt.Sprintf("Field %d", i+1)
+			for _, f := range t.Fields {
+				if f.Ord == i {
+					name = f.Name
+				}
+			}
+			n.Fields = append(n.Fields, Field{Name: name, Value: value})
+		}
+		c.Notes = append(c.Notes, n)
+	}
+	return rows.Err()
+}
+
+func readCards(db *sql.DB, c *Collection) error {
+	rows, err := db.Query(`SELECT id, nid, ord FROM cards ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var card Card
+		if err := rows.Scan(&card.ID, &card.NoteID, &card.Ord); err != nil {
+			return err
+		}
+		c.Cards = append(c.Cards, card)
+	}
+	return rows.Err()
+}
+
+func readReviews(db *sql.DB, c *Collection) error {
+	rows, err := db.Query(`SELECT id, cid, ease, time, type FROM revlog ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, ms int64
+		var r Review
+		if err := rows.Scan(&id, &r.CardID, &r.Ease, &ms, &r.Type); err != nil {
+			return err
+		}
+		// Revlog ids are the review time in milliseconds.
+		r.Time = time.UnixMilli(id)
+		r.Duration = time.Duration(ms) * time.Millisecond
+		c.Reviews = append(c.Reviews, r)
+	}
+	return rows.Err()
+}
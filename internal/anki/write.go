@@ -0,0 +1,322 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Fixed ids in written collections: Anki remaps them on import.
+const (
+	writeDeckID   = 1700000000001
+	writeModelID  = 1700000000002
+	defaultDeckID = 1
+	deckConfigID  = 1
+)
+
+// collectionSchema is the legacy (schema 11) collection layout, which all
+// Anki versions can import.
+const collectionSchema = `
+CREATE TABLE col (
+	id integer primary key, crt integer not null, mod integer not null,
+	scm integer not null, ver integer not null, dty integer not null,
+	usn integer not null, ls integer not null, conf text not null,
+	models text not null, decks text not null, dconf text not null,
+	tags text not null
+);
+CREATE TABLE notes (
+	id integer primary key, guid text not null, mid integer not null,
+	mod integer not null, usn integer not null, tags text not null,
+	flds text not null, sfld integer not null, csum integer not null,
+	flags integer not null, data text not null
+);
+CREATE TABLE cards (
+	id integer primary key, nid integer not null, did integer not null,
+	ord integer not null, mod integer not null, usn integer not null,
+	type integer not null, queue integer not null, due integer not null,
+	ivl integer not null, factor integer not null, reps integer not null,
+	lapses integer not null, left integer not null, odue integer not null,
+	odid integer not null, flags integer not null, data text not null
+);
+CREATE TABLE revlog (
+	id integer primary key, cid integer not null, usn integer not null,
+	ease integer not null, ivl integer not null, lastIvl integer not null,
+	factor integer not null, time integer not null, type integer not null
+);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn on notes (usn);
+CREATE INDEX ix_cards_usn on cards (usn);
+CREATE INDEX ix_revlog_usn on revlog (usn);
+CREATE INDEX ix_cards_nid on cards (nid);
+CREATE INDEX ix_cards_sched on cards (did, queue, due);
+CREATE INDEX ix_revlog_cid on revlog (cid);
+CREATE INDEX ix_notes_csum on notes (csum);
+`
+
+// defaultFactor is Anki's starting ease (250%). recall schedules with
+// FSRS, whose state goes into the card data instead.
+const defaultFactor = 2500
+
+// Write saves the collection as an .apkg package at path. All notes use
+// one note type named after the first note's Model, with its fields, and
+// a card showing the first field and then the rest.
+func Write(path string, c *Collection) error {
+	if len(c.Notes) == 0 {
+		return fmt.Errorf("no notes to write")
+	}
+
+	dir, err := os.MkdirTemp("", "recall-anki-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, "collection.anki21")
+	if err := writeCollection(dbPath, c); err != nil {
+		return fmt.Errorf("writing Anki collection: %w", err)
+	}
+	return writePackage(path, dbPath)
+}
+
+func writeCollection(dbPath string, c *Collection) error {
+	db, err := sql.Open("sqlite", "file:"+dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(collectionSchema); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	conf, models, decks, dconf := collectionJSON(c, now)
+	_, err = tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		c.Created.Unix(), now.UnixMilli(), now.UnixMilli(), conf, models, decks, dconf)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range c.Notes {
+		values := make([]string, len(n.Fields))
+		for i, f := range n.Fields {
+			values[i] = f.Value
+		}
+		sortField := PlainText(values[0])
+		_, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			n.ID, n.GUID, writeModelID, now.Unix(), formatTags(n.Tags),
+			strings.Join(values, "\x1f"), sortField, checksum(sortField))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, card := range c.Cards {
+		factor := defaultFactor
+		if card.Type == CardNew {
+			factor = 0
+		}
+		_, err := tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, ?, ?, -1, ?, ?, ?, ?, ?, ?, ?, 0, 0, 0, 0, ?)`,
+			card.ID, card.NoteID, writeDeckID, card.Ord, now.Unix(), card.Type, card.Queue,
+			card.Due, card.Interval, factor, card.Reps, card.Lapses, card.Data)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Revlog ids are millisecond timestamps and must be unique.
+	used := make(map[int64]bool)
+	for _, r := range c.Reviews {
+		id := r.Time.UnixMilli()
+		for used[id] {
+			id++
+		}
+		used[id] = true
+
+		factor := defaultFactor
+		if r.Type == ReviewLearn || r.Type == ReviewManual {
+			factor = 0
+		}
+		_, err := tx.Exec(`INSERT INTO revlog VALUES (?, ?, -1, ?, ?, ?, ?, ?, ?)`,
+			id, r.CardID, r.Ease, r.Interval, r.LastInterval, factor,
+			r.Duration.Milliseconds(), r.Type)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// collectionJSON builds the JSON settings of the col table: collection
+// config, the note type, the decks and the deck options.
+func collectionJSON(c *Collection, now time.Time) (conf, models, decks, dconf string) {
+	mod := now.Unix()
+
+	conf = mustJSON(map[string]any{
+		"schedVer":      2,
+		"rollover":      c.DayStart,
+		"nextPos":       len(c.Cards) + 1,
+		"curDeck":       writeDeckID,
+		"activeDecks":   []int64{writeDeckID},
+		"sortType":      "noteFld",
+		"sortBackwards": false,
+		"addToCur":      true,
+		"collapseTime":  1200,
+		"timeLim":       0,
+		"estTimes":      true,
+		"dueCounts":     true,
+		"newSpread":     0,
+	})
+
+	var fieldNames []string
+	name := "recall"
+	if len(c.Notes) > 0 {
+		name = c.Notes[0].Model
+		for _, f := range c.Notes[0].Fields {
+			fieldNames = append(fieldNames, f.Name)
+		}
+	}
+	var fields []map[string]any
+	for i, f := range fieldNames {
+		fields = append(fields, map[string]any{
+			"name": f, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		})
+	}
+	back := "{{FrontSide}}\n\n<hr id=answer>\n\n"
+	for _, f := range fieldNames[1:] {
+		back += "{{" + f + "}}\n"
+	}
+	models = mustJSON(map[string]any{
+		fmt.Sprint(writeModelID): map[string]any{
+			"id": writeModelID, "name": name, "type": 0, "mod": mod, "usn": -1,
+			"sortf": 0, "did": writeDeckID, "flds": fields,
+			"tmpls": []map[string]any{{
+				"name": "Card 1", "ord": 0, "qfmt": "{{" + fieldNames[0] + "}}", "afmt": back,
+				"bqfmt": "", "bafmt": "", "did": nil,
+			}},
+			"css":       ".card { font-family: arial; font-size: 20px; text-align: left; }",
+			"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+			"latexPost": "\\end{document}",
+			"req":       []any{[]any{0, "any", []int{0}}},
+			"tags":      []string{},
+			"vers":      []any{},
+		},
+	})
+
+	deck := func(id int64, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "mod": mod, "usn": -1, "desc": "", "dyn": 0,
+			"conf": deckConfigID, "collapsed": false, "extendNew": 10, "extendRev": 50,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0},
+			"lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+		}
+	}
+	decks = mustJSON(map[string]any{
+		fmt.Sprint(defaultDeckID): deck(defaultDeckID, "Default"),
+		fmt.Sprint(writeDeckID):   deck(writeDeckID, c.Deck),
+	})
+
+	dconf = mustJSON(map[string]any{
+		fmt.Sprint(deckConfigID): map[string]any{
+			"id": deckConfigID, "name": "Default", "mod": 0, "usn": 0,
+			"maxTaken": 60, "autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+			"new": map[string]any{
+				"delays": []float64{1, 10}, "ints": []int{1, 4, 0}, "initialFactor": defaultFactor,
+				"order": 1, "perDay": 20, "bury": false,
+			},
+			"rev": map[string]any{
+				"perDay": 200, "ease4": 1.3, "ivlFct": 1, "maxIvl": 36500,
+				"hardFactor": 1.2, "bury": false,
+			},
+			"lapse": map[string]any{
+				"delays": []float64{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 1,
+			},
+		},
+	})
+	return conf, models, decks, dconf
+}
+
+func writePackage(path, dbPath string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+
+	err = func() error {
+		w, err := zw.Create("collection.anki21")
+		if err != nil {
+			return err
+		}
+		db, err := os.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if _, err := io.Copy(w, db); err != nil {
+			return err
+		}
+
+		// No media files.
+		w, err = zw.Create("media")
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "{}")
+		return err
+	}()
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// formatTags joins tags the way Anki stores them: space separated, with
+// surrounding spaces. Spaces inside a tag become underscores.
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	clean := make([]string, len(tags))
+	for i, t := range tags {
+		clean[i] = strings.ReplaceAll(t, " ", "_")
+	}
+	return " " + strings.Join(clean, " ") + " "
+}
+
+// checksum is Anki's duplicate check: the first 8 hex digits of the SHA-1
+// of the sort field.
+func checksum(sortField string) int64 {
+	sum := sha1.Sum([]byte(sortField))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+func mustJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
@@ -2,12 +2,58 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
 )
 
+// DefaultDayStart is the hour a new day starts at, like in Anki: reviews
+// until 4am still count towards the previous day.
+const DefaultDayStart = 4
+
 type Config struct {
 	WikiPath string `json:"wiki_path"`
+
+	// Storage backend: "json" (default) or "sqlite".
+	Backend string `json:"backend,omitempty"`
+
+	// Scheduler settings for the whole wiki.
+	SchedulerSettings
+
+	// Fuzz spreads review intervals randomly by a few percent, so topics
+	// read on the same day do not stay due on the same day.
+	Fuzz bool `json:"fuzz,omitempty"`
+
+	// LoadBalance moves review intervals, within the fuzz range, towards
+	// days with fewer reviews due.
+	LoadBalance bool `json:"load_balance,omitempty"`
+
+	// SyncFrontmatter writes each topic's schedule into the frontmatter of
+	// its file, for tools like Obsidian Dataview that read only the notes.
+	SyncFrontmatter bool `json:"sync_frontmatter,omitempty"`
+
+	// Daily limits on topics first read and on reviews; 0 means no limit.
+	NewPerDay     int `json:"new_per_day,omitempty"`
+	ReviewsPerDay int `json:"reviews_per_day,omitempty"`
+
+	// TagPriority orders the new-topic queue: topics with tags listed
+	// earlier are introduced first.
+	TagPriority []string `json:"tag_priority,omitempty"`
+
+	// DayStart is the hour (0-23) a new day starts at; nil means
+	// DefaultDayStart.
+	DayStart *int `json:"day_start,omitempty"`
+
+	// Timezone pins days to an IANA zone such as "Europe/Berlin", so due
+	// dates stay put when traveling. Empty means the system zone.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Per-tag scheduler overrides, keyed by tag name.
+	Tags map[string]SchedulerSettings `json:"tags,omitempty"`
 }
 
 func DefaultConfigPath() string {
@@ -50,6 +96,47 @@ func Save(cfg *Config) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
+// Clock returns the scheduling days defined by DayStart and Timezone.
+func (c *Config) Clock() (fsrs.Clock, error) {
+	clock := fsrs.Clock{Location: localZone(), DayStart: DefaultDayStart}
+	if c == nil {
+		return clock, nil
+	}
+
+	if c.DayStart != nil {
+		if *c.DayStart < 0 || *c.DayStart > 23 {
+			return clock, fmt.Errorf("day_start must be between 0 and 23")
+		}
+		clock.DayStart = *c.DayStart
+	}
+	if c.Timezone != "" {
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return clock, fmt.Errorf("invalid timezone: %s", c.Timezone)
+		}
+		clock.Location = loc
+	}
+	return clock, nil
+}
+
+// localZone returns the system time zone, loaded by name where possible
+// so that it is recorded as e.g. "Europe/Berlin" rather than "Local".
+func localZone() *time.Location {
+	name := os.Getenv("TZ")
+	if name == "" {
+		target, err := os.Readlink("/etc/localtime")
+		if err != nil {
+			return time.Local
+		}
+		_, name, _ = strings.Cut(target, "zoneinfo/")
+	}
+	loc, err := time.LoadLocation(strings.TrimPrefix(name, ":"))
+	if err != nil || name == "" {
+		return time.Local
+	}
+	return loc
+}
+
 func GetWikiPath() (string, error) {
 	cfg, err := Load()
 	if err != nil {
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+)
+
+// SchedulerSettings overrides FSRS parameters. Zero values fall back to the
+// next level (wiki settings, then FSRS defaults).
+type SchedulerSettings struct {
+	RequestRetention float64   `json:"request_retention,omitempty"`
+	MaximumInterval  int       `json:"maximum_interval,omitempty"`
+	Weights          []float64 `json:"weights,omitempty"`
+}
+
+const (
+	MinRequestRetention = 0.70
+	MaxRequestRetention = 0.99
+	MaxMaximumInterval  = 36500
+)
+
+// Validate checks that every non-zero setting is within range.
+func (s SchedulerSettings) Validate() error {
+	if s.RequestRetention != 0 && (s.RequestRetention < MinRequestRetention || s.RequestRetention > MaxRequestRetention) {
+		return fmt.Errorf("request_retention must be between %.2f and %.2f", MinRequestRetention, MaxRequestRetention)
+	}
+	if s.MaximumInterval < 0 || s.MaximumInterval > MaxMaximumInterval {
+		return fmt.Errorf("maximum_interval must be between 1 and %d", MaxMaximumInterval)
+	}
+	if len(s.Weights) > 0 {
+		if err := fsrs.ValidateWeights(s.Weights); err != nil {
+			return fmt.Errorf("weights: %w", err)
+		}
+	}
+	return nil
+}
+
+// Apply returns params with every non-zero setting applied on top.
+func (s SchedulerSettings) Apply(params fsrs.Parameters) fsrs.Parameters {
+	if s.RequestRetention != 0 {
+		params.RequestRetention = s.RequestRetention
+	}
+	if s.MaximumInterval != 0 {
+		params.MaximumInterval = s.MaximumInterval
+	}
+	if len(s.Weights) > 0 {
+		params.W = s.Weights
+	}
+	return params
+}
+
+// SchedulerParams resolves the FSRS parameters for a topic with the given
+// tags. Wiki settings apply first, then tag overrides in the order the
+// tags are listed; for each setting the first tag that sets it wins.
+func (c *Config) SchedulerParams(tags []string) (fsrs.Parameters, error) {
+	params := fsrs.DefaultParameters()
+	if c == nil {
+		return params, nil
+	}
+
+	if err := c.SchedulerSettings.Validate(); err != nil {
+		return params, err
+	}
+	params = c.SchedulerSettings.Apply(params)
+
+	var merged SchedulerSettings
+	for _, tag := range tags {
+		override, ok := c.Tags[tag]
+		if !ok {
+			continue
+		}
+		if err := override.Validate(); err != nil {
+			return params, fmt.Errorf("tag %s: %w", tag, err)
+		}
+		if merged.RequestRetention == 0 {
+			merged.RequestRetention = override.RequestRetention
+		}
+		if merged.MaximumInterval == 0 {
+			merged.MaximumInterval = override.MaximumInterval
+		}
+		if len(merged.Weights) == 0 {
+			merged.Weights = override.Weights
+		}
+	}
+
+	return merged.Apply(params), nil
+}
@@ -0,0 +1,68 @@
+package fsrs
+
+import "time"
+
+// Clock divides time into scheduling days. A day starts at DayStart
+// o'clock in Location, so reviews late at night and just after midnight
+// fall on the same day, and intervals count days rather than hours.
+//
+// The zero Clock uses days from midnight to midnight in time.Local.
+type Clock struct {
+	Location *time.Location // nil means time.Local
+	DayStart int            // Hour of the day, 0-23
+}
+
+func (c Clock) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// Zone is the name of the clock's time zone, e.g. "Europe/Berlin".
+func (c Clock) Zone() string {
+	return c.location().String()
+}
+
+// In returns t in the clock's time zone.
+func (c Clock) In(t time.Time) time.Time {
+	return t.In(c.location())
+}
+
+// StartOfDay returns when the scheduling day containing t began.
+func (c Clock) StartOfDay(t time.Time) time.Time {
+	t = c.In(t)
+	start := time.Date(t.Year(), t.Month(), t.Day(), c.DayStart, 0, 0, 0, c.location())
+	if t.Before(start) {
+		start = time.Date(t.Year(), t.Month(), t.Day()-1, c.DayStart, 0, 0, 0, c.location())
+	}
+	return start
+}
+
+// EndOfDay returns the last moment of the scheduling day containing t.
+func (c Clock) EndOfDay(t time.Time) time.Time {
+	return c.AddDays(t, 1).Add(-time.Nanosecond)
+}
+
+// AddDays returns the start of the scheduling day n days after the one
+// containing t.
+func (c Clock) AddDays(t time.Time, n int) time.Time {
+	start := c.StartOfDay(t)
+	return time.Date(start.Year(), start.Month(), start.Day()+n, c.DayStart, 0, 0, 0, c.location())
+}
+
+// Date is the calendar date a scheduling day is named after, e.g. a
+// review at 2am with a 4am day start belongs to the previous date.
+func (c Clock) Date(t time.Time) string {
+	return c.StartOfDay(t).Format(time.DateOnly)
+}
+
+// Days counts the scheduling days from the one containing a to the one
+// containing b; it is negative when b is on an earlier day.
+func (c Clock) Days(a, b time.Time) int {
+	da, db := c.StartOfDay(a), c.StartOfDay(b)
+	// Compare dates at noon UTC, so DST changes cannot round a day away.
+	ua := time.Date(da.Year(), da.Month(), da.Day(), 12, 0, 0, 0, time.UTC)
+	ub := time.Date(db.Year(), db.Month(), db.Day(), 12, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
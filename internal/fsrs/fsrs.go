@@ -2,6 +2,7 @@ package fsrs
 
 import (
 	"math"
+	"math/rand/v2"
 	"time"
 )
 
@@ -14,17 +15,34 @@ const (
 // FSRS is the main scheduler.
 type FSRS struct {
 	Params Parameters
+
+	// Rand enables interval fuzz (see adjustInterval). Seed it for
+	// reproducible schedules.
+	Rand *rand.Rand
+
+	// Load, if set, returns how many reviews are already due on the given
+	// day; intervals are then nudged towards quieter days.
+	Load func(day time.Time) int
+
+	// Clock defines the days that intervals and elapsed time are counted
+	// in. Cards come due at the start of a day.
+	Clock Clock
 }
 
 func NewScheduler() *FSRS {
 	return &FSRS{Params: DefaultParameters()}
 }
 
+// NewSchedulerWithParams creates a scheduler using custom parameters.
+func NewSchedulerWithParams(params Parameters) *FSRS {
+	return &FSRS{Params: params}
+}
+
 func (f *FSRS) Review(card Card, rating Rating, now time.Time) Card {
 	elapsedDays := f.elapsedDays(card, now)
 	retrievability := f.retrievability(card.Stability, elapsedDays)
 
-	card.LastReview = now
+	card.LastReview = f.Clock.In(now)
 	card.Reps++
 
 	if card.State == New {
@@ -33,11 +51,36 @@ func (f *FSRS) Review(card Card, rating Rating, now time.Time) Card {
 		card = f.updateCard(card, rating, retrievability)
 	}
 
-	card.Due = now.AddDate(0, 0, f.nextInterval(card.Stability))
+	card.Due = f.Clock.AddDays(now, f.adjustInterval(f.nextInterval(card.Stability), now))
 
 	return card
 }
 
+// Replay rebuilds a card from its rating history, oldest first.
+func (f *FSRS) Replay(history []ReviewEvent) Card {
+	card := startCard(history)
+	for _, ev := range history {
+		card = f.Review(card, ev.Rating, ev.Time)
+	}
+	return card
+}
+
+// startCard is the card a history is replayed from: the card before the
+// first rating if it was already scheduled, else a new card.
+func startCard(history []ReviewEvent) Card {
+	if len(history) > 0 && history[0].Before != nil && history[0].Before.State != New {
+		return *history[0].Before
+	}
+	return NewCard()
+}
+
+// Retrievability is the estimated probability of recalling the card at
+// the given time, counting elapsed days with clock.
+func Retrievability(card Card, now time.Time, clock Clock) float64 {
+	f := FSRS{Clock: clock}
+	return f.retrievability(card.Stability, f.elapsedDays(card, now))
+}
+
 func (f *FSRS) initializeCard(card Card, rating Rating) Card {
 	card.Difficulty = f.initDifficulty(rating)
 	card.Stability = f.initStability(rating)
@@ -153,12 +196,12 @@ func (f *FSRS) elapsedDays(card Card, now time.Time) float64 {
 		return 0
 	}
 
-	days := now.Sub(card.LastReview).Hours() / 24
+	days := f.Clock.Days(card.LastReview, now)
 	if days < 0 {
 		return 0
 	}
 
-	return days
+	return float64(days)
 }
 
 func (f *FSRS) retrievability(stability, elapsedDays float64) float64 {
@@ -0,0 +1,42 @@
+package fsrs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplaySeeded(t *testing.T) {
+	f := NewScheduler()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	seeded := FromSM2(f.Clock, 30, 250, start.AddDate(0, 0, 30))
+
+	// A seeded card's first rating builds on the seeded schedule.
+	at := start.AddDate(0, 0, 31)
+	history := []ReviewEvent{{Rating: Good, Time: at, Before: &seeded}}
+	want := f.Review(seeded, Good, at)
+	if got := f.Replay(history); got != want {
+		t.Errorf("Replay(seeded) = %+v, want %+v", got, want)
+	}
+
+	// A Before that was new replays from scratch as before.
+	fresh := NewCard()
+	history = []ReviewEvent{{Rating: Good, Time: at, Before: &fresh}}
+	if got, want := f.Replay(history), f.Review(NewCard(), Good, at); got != want {
+		t.Errorf("Replay(new) = %+v, want %+v", got, want)
+	}
+}
+
+func TestEvaluateSeeded(t *testing.T) {
+	f := NewScheduler()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	seeded := FromSM2(f.Clock, 30, 250, start.AddDate(0, 0, 30))
+	at := start.AddDate(0, 0, 31)
+
+	// The first rating of a seeded card tests recall; a new card's does not.
+	if got := Evaluate(f.Params, f.Clock, [][]ReviewEvent{{{Rating: Good, Time: at, Before: &seeded}}}).Reviews; got != 1 {
+		t.Errorf("seeded card scored %d reviews, want 1", got)
+	}
+	if got := Evaluate(f.Params, f.Clock, [][]ReviewEvent{{{Rating: Good, Time: at}}}).Reviews; got != 0 {
+		t.Errorf("new card scored %d reviews, want 0", got)
+	}
+}
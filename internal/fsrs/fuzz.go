@@ -0,0 +1,83 @@
+package fsrs
+
+import (
+	"math"
+	"time"
+)
+
+// fuzzRanges are Anki's fuzz factors: the part of an interval that falls
+// in each range may move by that fraction. Intervals under 2.5 days are
+// never fuzzed.
+var fuzzRanges = []struct {
+	start, end, factor float64
+}{
+	{2.5, 7, 0.15},
+	{7, 20, 0.1},
+	{20, math.Inf(1), 0.05},
+}
+
+// fuzzRange returns the smallest and largest interval, in days, that an
+// interval may be moved to.
+func (f *FSRS) fuzzRange(interval int) (lo, hi int) {
+	ivl := float64(interval)
+	if ivl < 2.5 {
+		return interval, interval
+	}
+
+	delta := 1.0
+	for _, r := range fuzzRanges {
+		delta += r.factor * max(min(ivl, r.end)-r.start, 0)
+	}
+
+	lo = max(int(math.Round(ivl-delta)), 2)
+	hi = min(int(math.Round(ivl+delta)), f.Params.MaximumInterval)
+	lo = min(lo, hi)
+	return lo, hi
+}
+
+// adjustInterval applies fuzz and load balancing to an interval scheduled
+// at now. Without Rand or Load it returns the interval unchanged.
+//
+// With only Rand, a day is picked uniformly from the fuzz range. With
+// Load, days with fewer reviews are preferred: each day is weighted by
+// 1/(load+1)² and, slightly, by shorter intervals. The pick is random when
+// Rand is set and the best weight otherwise.
+func (f *FSRS) adjustInterval(interval int, now time.Time) int {
+	if f.Rand == nil && f.Load == nil {
+		return interval
+	}
+
+	lo, hi := f.fuzzRange(interval)
+	if lo == hi {
+		return interval
+	}
+	if f.Load == nil {
+		return lo + f.Rand.IntN(hi-lo+1)
+	}
+
+	weights := make([]float64, hi-lo+1)
+	total := 0.0
+	best := lo
+	for i := range weights {
+		days := lo + i
+		load := float64(f.Load(f.Clock.AddDays(now, days)))
+		weights[i] = 1 / ((load + 1) * (load + 1)) / float64(days)
+		total += weights[i]
+		if weights[i] > weights[best-lo] {
+			best = days
+		}
+	}
+
+	if f.Rand == nil {
+		return best
+	}
+
+	pick := f.Rand.Float64() * total
+	for i, w := range weights {
+		pick -= w
+		if pick < 0 {
+			return lo + i
+		}
+	}
+	return hi
+}
@@ -0,0 +1,133 @@
+package fsrs
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestFuzzRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    int
+		maxInterval int
+		lo, hi      int
+	}{
+		{"one day is never fuzzed", 1, 1825, 1, 1},
+		{"two days are never fuzzed", 2, 1825, 2, 2},
+		{"just past 2.5 days", 3, 1825, 2, 4},
+		{"end of the 15% range", 7, 1825, 5, 9},
+		{"end of the 10% range", 20, 1825, 17, 23},
+		{"in the 5% range", 100, 1825, 93, 107},
+		{"clamped to the maximum", 100, 100, 93, 100},
+		{"maximum below the range", 20, 5, 5, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultParameters()
+			params.MaximumInterval = tt.maxInterval
+			lo, hi := NewSchedulerWithParams(params).fuzzRange(tt.interval)
+			if lo != tt.lo || hi != tt.hi {
+				t.Errorf("fuzzRange(%d) = %d, %d, want %d, %d", tt.interval, lo, hi, tt.lo, tt.hi)
+			}
+		})
+	}
+}
+
+func TestAdjustInterval(t *testing.T) {
+	clock := Clock{Location: time.UTC, DayStart: 4}
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// An interval of 10 days may move to 8-12 days.
+	const interval, lo, hi = 10, 8, 12
+
+	// loadBy returns a Load stub with the given reviews due n days after
+	// now, and busy reviews on every other day.
+	loadBy := func(busy int, loads map[int]int) func(time.Time) int {
+		return func(day time.Time) int {
+			if n, ok := loads[clock.Days(now, day)]; ok {
+				return n
+			}
+			return busy
+		}
+	}
+
+	tests := []struct {
+		name string
+		seed uint64 // 0 for no Rand
+		load func(time.Time) int
+		// want is the exact result without Rand; with it, quiet is the day
+		// that must win at least minShare of draws (0 for every day).
+		want     int
+		quiet    int
+		minShare float64
+	}{
+		{name: "off", want: interval},
+		{name: "load picks the quietest day", load: loadBy(5, map[int]int{11: 0}), want: 11},
+		{name: "equal load picks the shortest", load: loadBy(3, nil), want: lo},
+		{name: "fuzz is uniform", seed: 1, minShare: 0.15},
+		{name: "fuzz prefers quiet days", seed: 2, load: loadBy(9, map[int]int{11: 0}), quiet: 11, minShare: 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewScheduler()
+			f.Clock = clock
+			f.Load = tt.load
+			if tt.seed == 0 {
+				if got := f.adjustInterval(interval, now); got != tt.want {
+					t.Errorf("adjustInterval(%d) = %d, want %d", interval, got, tt.want)
+				}
+				return
+			}
+
+			f.Rand = rand.New(rand.NewPCG(tt.seed, 0))
+			const draws = 2000
+			counts := make(map[int]int)
+			for range draws {
+				got := f.adjustInterval(interval, now)
+				if got < lo || got > hi {
+					t.Fatalf("adjustInterval(%d) = %d, outside %d-%d", interval, got, lo, hi)
+				}
+				counts[got]++
+			}
+			days := []int{tt.quiet}
+			if tt.quiet == 0 {
+				days = []int{lo, lo + 1, lo + 2, lo + 3, hi}
+			}
+			for _, day := range days {
+				if share := float64(counts[day]) / draws; share < tt.minShare {
+					t.Errorf("day %d drawn %.2f of the time, want at least %.2f (%v)", day, share, tt.minShare, counts)
+				}
+			}
+		})
+	}
+}
+
+func TestAdjustIntervalSeeded(t *testing.T) {
+	// The same seed gives the same schedule.
+	draw := func() []int {
+		f := NewScheduler()
+		f.Rand = rand.New(rand.NewPCG(42, 7))
+		var got []int
+		for range 20 {
+			got = append(got, f.adjustInterval(30, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+		}
+		return got
+	}
+
+	a, b := draw(), draw()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("draw %d differs: %d vs %d", i, a[i], b[i])
+		}
+	}
+
+	// Short intervals are left alone even with fuzz on.
+	f := NewScheduler()
+	f.Rand = rand.New(rand.NewPCG(1, 1))
+	if got := f.adjustInterval(2, time.Now()); got != 2 {
+		t.Errorf("adjustInterval(2) = %d, want 2", got)
+	}
+}
@@ -0,0 +1,176 @@
+package fsrs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// MinOptimizeReviews is the minimum number of predictable reviews needed
+// before fitting weights is attempted.
+const MinOptimizeReviews = 20
+
+var ErrNotEnoughReviews = errors.New("not enough review history to optimize")
+
+// ReviewEvent is one rating in a card's history.
+type ReviewEvent struct {
+	Rating Rating
+	Time   time.Time
+
+	// Before is the card as it was before the rating, if known. A history
+	// whose first event has a scheduled Before, such as a card seeded by
+	// an import, is replayed from that card instead of a new one.
+	Before *Card
+}
+
+// Metrics describes how well a set of parameters predicts recall.
+type Metrics struct {
+	LogLoss float64
+	RMSE    float64
+	Reviews int // Number of reviews that contributed a prediction
+}
+
+// weightBounds keeps each weight in a range where the model stays sane.
+var weightBounds = [][2]float64{
+	{0.01, 100}, {0.01, 100}, {0.01, 100}, {0.01, 100},
+	{1, 10},
+	{0.001, 4},
+	{0.001, 4},
+	{0.001, 0.75},
+	{0, 4.5},
+	{0, 0.8},
+	{0.001, 3.5},
+	{0.001, 5},
+	{0.001, 0.25},
+	{0.001, 0.9},
+	{0, 4},
+	{0, 1},
+	{0.1, 6},
+}
+
+const (
+	optimizeIterations = 300
+	optimizeRate       = 0.005
+	probabilityEpsilon = 1e-4
+)
+
+// ValidateWeights checks that w has the expected length and every weight
+// falls within the optimizer bounds.
+func ValidateWeights(w []float64) error {
+	if len(w) != len(weightBounds) {
+		return fmt.Errorf("expected %d weights, got %d", len(weightBounds), len(w))
+	}
+	for i, v := range w {
+		b := weightBounds[i]
+		if math.IsNaN(v) || v < b[0] || v > b[1] {
+			return fmt.Errorf("weight w[%d]=%g out of range [%g, %g]", i, v, b[0], b[1])
+		}
+	}
+	return nil
+}
+
+// Evaluate replays every history with params, counting days with clock,
+// and reports the log-loss and RMSE of the predicted retrievability
+// against the actual outcome
+// (anything but Again counts as recalled). First reviews of new cards and
+// reviews less than a day after the previous one are replayed but not
+// scored.
+func Evaluate(params Parameters, clock Clock, histories [][]ReviewEvent) Metrics {
+	f := &FSRS{Params: params, Clock: clock}
+
+	var logLoss, sqErr float64
+	n := 0
+	for _, history := range histories {
+		card := startCard(history)
+		for _, ev := range history {
+			if card.State != New {
+				elapsed := f.elapsedDays(card, ev.Time)
+				if elapsed >= 1 {
+					p := clamp(f.retrievability(card.Stability, elapsed), probabilityEpsilon, 1-probabilityEpsilon)
+					y := 0.0
+					if ev.Rating != Again {
+						y = 1
+					}
+					logLoss -= y*math.Log(p) + (1-y)*math.Log(1-p)
+					sqErr += (p - y) * (p - y)
+					n++
+				}
+			}
+			card = f.Review(card, ev.Rating, ev.Time)
+		}
+	}
+
+	if n == 0 {
+		return Metrics{}
+	}
+	return Metrics{
+		LogLoss: logLoss / float64(n),
+		RMSE:    math.Sqrt(sqErr / float64(n)),
+		Reviews: n,
+	}
+}
+
+// Optimize fits the model weights to the given histories by minimizing
+// log-loss, starting from params, with days counted by clock as when
+// scheduling. Retention and maximum interval are kept as-is. The returned
+// parameters never score worse than the starting point.
+func Optimize(params Parameters, clock Clock, histories [][]ReviewEvent) (Parameters, error) {
+	start := Evaluate(params, clock, histories)
+	if start.Reviews < MinOptimizeReviews {
+		return params, fmt.Errorf("%w: %d reviews, need %d", ErrNotEnoughReviews, start.Reviews, MinOptimizeReviews)
+	}
+
+	w := make([]float64, len(weightBounds))
+	copy(w, params.W)
+	for i := range w {
+		w[i] = clamp(w[i], weightBounds[i][0], weightBounds[i][1])
+	}
+
+	loss := func(w []float64) float64 {
+		p := params
+		p.W = w
+		return Evaluate(p, clock, histories).LogLoss
+	}
+
+	best := append([]float64(nil), w...)
+	bestLoss := start.LogLoss
+
+	// Adam with central-difference gradients.
+	const beta1, beta2, eps = 0.9, 0.999, 1e-8
+	m := make([]float64, len(w))
+	v := make([]float64, len(w))
+	grad := make([]float64, len(w))
+
+	for iter := 1; iter <= optimizeIterations; iter++ {
+		for i := range w {
+			h := math.Max(math.Abs(w[i])*1e-3, 1e-5)
+			orig := w[i]
+			w[i] = orig + h
+			up := loss(w)
+			w[i] = orig - h
+			down := loss(w)
+			w[i] = orig
+			grad[i] = (up - down) / (2 * h)
+		}
+
+		// Cosine-decayed learning rate, scaled per weight by its range.
+		rate := optimizeRate * 0.5 * (1 + math.Cos(math.Pi*float64(iter-1)/optimizeIterations))
+		for i := range w {
+			m[i] = beta1*m[i] + (1-beta1)*grad[i]
+			v[i] = beta2*v[i] + (1-beta2)*grad[i]*grad[i]
+			mHat := m[i] / (1 - math.Pow(beta1, float64(iter)))
+			vHat := v[i] / (1 - math.Pow(beta2, float64(iter)))
+			step := rate * (weightBounds[i][1] - weightBounds[i][0]) * mHat / (math.Sqrt(vHat) + eps)
+			w[i] = clamp(w[i]-step, weightBounds[i][0], weightBounds[i][1])
+		}
+
+		if l := loss(w); l < bestLoss {
+			bestLoss = l
+			copy(best, w)
+		}
+	}
+
+	params.W = best
+	return params, nil
+}
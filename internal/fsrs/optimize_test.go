@@ -0,0 +1,109 @@
+package fsrs
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// simulate returns review histories of cards drawn from a learner whose
+// memory follows truth: each card is reviewed when due, and recalled with
+// the retrievability truth predicts.
+func simulate(truth Parameters, clock Clock, cards, reviews int, seed uint64) [][]ReviewEvent {
+	rng := rand.New(rand.NewPCG(seed, 0))
+	f := &FSRS{Params: truth, Clock: clock}
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	histories := make([][]ReviewEvent, 0, cards)
+	for range cards {
+		card := NewCard()
+		now := start
+		var history []ReviewEvent
+		for i := range reviews {
+			rating := Good
+			if i == 0 {
+				rating = Rating(1 + rng.IntN(4))
+			} else if rng.Float64() > Retrievability(card, now, clock) {
+				rating = Again
+			} else if rng.Float64() < 0.2 {
+				rating = Hard
+			}
+			history = append(history, ReviewEvent{Rating: rating, Time: now})
+			card = f.Review(card, rating, now)
+			// Reviewed a little late, with some spread.
+			now = card.Due.Add(time.Duration(rng.IntN(3*24)) * time.Hour)
+		}
+		histories = append(histories, history)
+	}
+	return histories
+}
+
+func TestOptimize(t *testing.T) {
+	if testing.Short() {
+		t.Skip("fitting takes a few seconds")
+	}
+	clock := Clock{Location: time.UTC, DayStart: 4}
+	truth := DefaultParameters()
+	truth.W = append([]float64(nil), truth.W...)
+	truth.W[2] *= 0.3 // Forgets Good first reads much sooner
+	truth.W[8] *= 0.6 // Gains less stability per review
+	histories := simulate(truth, clock, 30, 6, 1)
+
+	params := DefaultParameters()
+	before := Evaluate(params, clock, histories)
+	if before.Reviews < MinOptimizeReviews {
+		t.Fatalf("only %d scored reviews", before.Reviews)
+	}
+
+	fitted, err := Optimize(params, clock, histories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := Evaluate(fitted, clock, histories)
+	t.Logf("log loss %.4f -> %.4f over %d reviews", before.LogLoss, after.LogLoss, after.Reviews)
+	if after.LogLoss > before.LogLoss {
+		t.Errorf("log loss rose from %.4f to %.4f", before.LogLoss, after.LogLoss)
+	}
+	if after.LogLoss >= before.LogLoss-0.001 {
+		t.Errorf("log loss barely moved: %.4f to %.4f", before.LogLoss, after.LogLoss)
+	}
+	if err := ValidateWeights(fitted.W); err != nil {
+		t.Errorf("fitted weights out of bounds: %v", err)
+	}
+	if fitted.RequestRetention != params.RequestRetention || fitted.MaximumInterval != params.MaximumInterval {
+		t.Errorf("retention or maximum interval changed: %+v", fitted)
+	}
+}
+
+func TestOptimizeNotEnoughReviews(t *testing.T) {
+	clock := Clock{Location: time.UTC}
+	histories := simulate(DefaultParameters(), clock, 2, 3, 1)
+	params := DefaultParameters()
+
+	got, err := Optimize(params, clock, histories)
+	if !errors.Is(err, ErrNotEnoughReviews) {
+		t.Fatalf("err = %v, want ErrNotEnoughReviews", err)
+	}
+	if len(got.W) != len(params.W) || got.W[0] != params.W[0] {
+		t.Errorf("weights changed without enough reviews")
+	}
+}
+
+func TestEvaluateUsesClock(t *testing.T) {
+	// 03:00 and 05:00 are one day apart with a 4 am day start, but the
+	// same day with a midnight one, where the second rating is not scored.
+	day := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	history := [][]ReviewEvent{{
+		{Rating: Good, Time: day.Add(3 * time.Hour)},
+		{Rating: Good, Time: day.Add(5 * time.Hour)},
+	}}
+
+	params := DefaultParameters()
+	if got := Evaluate(params, Clock{Location: time.UTC}, history).Reviews; got != 0 {
+		t.Errorf("midnight day start scored %d reviews, want 0", got)
+	}
+	if got := Evaluate(params, Clock{Location: time.UTC, DayStart: 4}, history).Reviews; got != 1 {
+		t.Errorf("4 am day start scored %d reviews, want 1", got)
+	}
+}
@@ -0,0 +1,35 @@
+package fsrs
+
+import (
+	"math"
+	"time"
+)
+
+// SM-2 ease factors, as percentages, that map to the easiest and hardest
+// FSRS difficulty.
+const (
+	sm2MinEase = 130.0 // Lowest ease SM-2 allows
+	sm2MaxEase = 350.0
+)
+
+// FromSM2 approximates the FSRS card for an item scheduled by SM-2 with
+// the given interval in days, ease factor as a percentage (250 is SM-2's
+// starting ease) and due date.
+//
+// SM-2 intervals aim at roughly 90% recall, which is where FSRS
+// stability equals the interval. Difficulty falls linearly from 10 at the
+// minimum ease to 1 at 350%. The card is counted as reviewed once, on the
+// day the interval started.
+func FromSM2(clock Clock, interval, ease float64, due time.Time) Card {
+	days := max(int(math.Round(interval)), 1)
+	ease = min(max(ease, sm2MinEase), sm2MaxEase)
+
+	return Card{
+		Due:        clock.StartOfDay(due),
+		Stability:  max(interval, 0.1),
+		Difficulty: 10 - 9*(ease-sm2MinEase)/(sm2MaxEase-sm2MinEase),
+		Reps:       1,
+		State:      Review,
+		LastReview: clock.AddDays(due, -days),
+	}
+}
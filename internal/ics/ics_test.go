@@ -0,0 +1,115 @@
+package ics
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestWriteGolden(t *testing.T) {
+	cal := &Calendar{
+		Name: "recall: k8s, go",
+		Events: []Event{
+			{
+				UID:         "d8f41bbbc25a0214@recall",
+				Date:        time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC),
+				Summary:     `Review: Kubernetes#Pod lifecycle; init, sidecar \ ephemeral`,
+				Description: "File: kubernetes.md\nSection: Pod lifecycle",
+				Categories:  []string{"k8s", "a,b"},
+				Sequence:    1772353800,
+			},
+			{
+				// Long enough to fold, with a multi-byte rune on the fold.
+				UID:     "recall-20260311@recall",
+				Date:    time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC),
+				Summary: "Read: " + strings.Repeat("x", 56) + "Größenordnung und Maßstäbe in verteilten Systemen, Teil zwei",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.Write(&buf, time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.Bytes()
+
+	golden := filepath.Join("testdata", "calendar.ics")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run with -update to rewrite):\n%s", golden, got)
+	}
+
+	for i, line := range strings.SplitAfter(string(got), "\n") {
+		if line == "" {
+			continue
+		}
+		if !strings.HasSuffix(line, "\r\n") {
+			t.Errorf("line %d does not end in CRLF: %q", i+1, line)
+		}
+		if n := len(strings.TrimSuffix(line, "\r\n")); n > maxLineOctets {
+			t.Errorf("line %d is %d octets: %q", i+1, n, line)
+		}
+	}
+}
+
+func TestWriteLine(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"short", "SUMMARY:hi", "SUMMARY:hi\r\n"},
+		{"exactly the limit", strings.Repeat("a", 75), strings.Repeat("a", 75) + "\r\n"},
+		{
+			"folded",
+			strings.Repeat("a", 80),
+			strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 5) + "\r\n",
+		},
+		{
+			"continuations hold 74 octets",
+			strings.Repeat("a", 75+74+1),
+			strings.Repeat("a", 75) + "\r\n " + strings.Repeat("a", 74) + "\r\n a\r\n",
+		},
+		{
+			"multi-byte rune is not split",
+			strings.Repeat("a", 74) + "ö",
+			strings.Repeat("a", 74) + "\r\n ö\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeLine(&b, tt.in)
+			if b.String() != tt.want {
+				t.Errorf("writeLine(%q) = %q, want %q", tt.in, b.String(), tt.want)
+			}
+			// Unfolding gives back the original line.
+			if unfolded := strings.ReplaceAll(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ", ""); unfolded != tt.in {
+				t.Errorf("unfolded to %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	got := escapeText("a\\b;c,d\ne\r\nf")
+	want := `a\\b\;c\,d\ne\nf`
+	if got != want {
+		t.Errorf("escapeText = %q, want %q", got, want)
+	}
+}
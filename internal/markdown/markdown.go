@@ -0,0 +1,340 @@
+// Package markdown renders the common subset of Markdown used in wiki
+// notes to HTML: headings, paragraphs, lists, block quotes, fenced code,
+// tables, rules and inline formatting. All text is escaped, and raw HTML
+// in the source is shown as text.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingRegex      = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	ruleRegex         = regexp.MustCompile(`^\s{0,3}([-*_])(\s*([-*_])){2,}\s*$`)
+	fenceRegex        = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+-]*)")
+	bulletRegex       = regexp.MustCompile(`^(\s*)([-*+])\s+(.*)$`)
+	orderedRegex      = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	taskRegex         = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	tableDividerRegex = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// ToHTML renders Markdown source as HTML.
+func ToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	renderBlocks(&b, lines)
+	return b.String()
+}
+
+func renderBlocks(b *strings.Builder, lines []string) {
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>")
+			b.WriteString(renderInline(strings.Join(para, "\n")))
+			b.WriteString("</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+			i++
+
+		case fenceRegex.MatchString(line):
+			flush()
+			m := fenceRegex.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++ // Closing fence
+			b.WriteString("<pre><code")
+			if m[2] != "" {
+				b.WriteString(` class="language-` + html.EscapeString(m[2]) + `"`)
+			}
+			b.WriteString(">")
+			b.WriteString(html.EscapeString(strings.Join(code, "\n")))
+			b.WriteString("</code></pre>\n")
+
+		case headingRegex.MatchString(trimmed):
+			flush()
+			m := headingRegex.FindStringSubmatch(trimmed)
+			tag := "h" + string(rune('0'+len(m[1])))
+			b.WriteString("<" + tag + ">" + renderInline(m[2]) + "</" + tag + ">\n")
+			i++
+
+		case ruleRegex.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quote []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				q := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quote = append(quote, strings.TrimPrefix(q, " "))
+				i++
+			}
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quote)
+			b.WriteString("</blockquote>\n")
+
+		case bulletRegex.MatchString(line) || orderedRegex.MatchString(line):
+			flush()
+			i = renderList(b, lines, i)
+
+		case strings.Contains(line, "|") && i+1 < len(lines) && tableDividerRegex.MatchString(lines[i+1]):
+			flush()
+			i = renderTable(b, lines, i)
+
+		default:
+			para = append(para, trimmed)
+			i++
+		}
+	}
+	flush()
+}
+
+// renderList renders the list starting at lines[start] and returns the
+// index of the first line after it. Lines indented past the marker belong
+// to the item and are rendered as nested blocks.
+func renderList(b *strings.Builder, lines []string, start int) int {
+	ordered := orderedRegex.MatchString(lines[start]) && !bulletRegex.MatchString(lines[start])
+	listIndent := indentOf(lines[start])
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">\n")
+
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		var content string
+		if m := bulletRegex.FindStringSubmatch(line); m != nil && !ordered && len(m[1]) == listIndent {
+			content = m[3]
+		} else if m := orderedRegex.FindStringSubmatch(line); m != nil && ordered && len(m[1]) == listIndent {
+			content = m[3]
+		} else {
+			break
+		}
+		i++
+
+		// Continuation lines: indented deeper than the marker, or blank
+		// lines followed by such a line.
+		item := []string{content}
+		for i < len(lines) {
+			next := lines[i]
+			if strings.TrimSpace(next) == "" {
+				if i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != "" && indentOf(lines[i+1]) > listIndent {
+					item = append(item, "")
+					i++
+					continue
+				}
+				break
+			}
+			if indentOf(next) <= listIndent {
+				break
+			}
+			item = append(item, dedent(next, listIndent+2))
+			i++
+		}
+
+		b.WriteString("<li>")
+		if m := taskRegex.FindStringSubmatch(item[0]); m != nil {
+			checked := ""
+			if m[1] != " " {
+				checked = " checked"
+			}
+			b.WriteString(`<input type="checkbox" disabled` + checked + `> `)
+			item[0] = item[0][len(m[0]):]
+		}
+		if len(item) == 1 {
+			b.WriteString(renderInline(item[0]))
+		} else {
+			var nested strings.Builder
+			renderBlocks(&nested, item)
+			b.WriteString(unwrapParagraph(nested.String()))
+		}
+		b.WriteString("</li>\n")
+
+		// A blank line between items keeps the list going.
+		if i+1 < len(lines) && strings.TrimSpace(lines[i]) == "" && sameList(lines[i+1], ordered, listIndent) {
+			i++
+		}
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+func sameList(line string, ordered bool, indent int) bool {
+	if ordered {
+		m := orderedRegex.FindStringSubmatch(line)
+		return m != nil && len(m[1]) == indent
+	}
+	m := bulletRegex.FindStringSubmatch(line)
+	return m != nil && len(m[1]) == indent
+}
+
+// unwrapParagraph drops the <p> around a list item's first line, so tight
+// items with nested lists do not gain extra spacing.
+func unwrapParagraph(s string) string {
+	if rest, ok := strings.CutPrefix(s, "<p>"); ok {
+		if end := strings.Index(rest, "</p>\n"); end >= 0 {
+			return rest[:end] + "\n" + rest[end+len("</p>\n"):]
+		}
+	}
+	return s
+}
+
+func renderTable(b *strings.Builder, lines []string, start int) int {
+	header := tableCells(lines[start])
+	b.WriteString("<table>\n<thead><tr>")
+	for _, c := range header {
+		b.WriteString("<th>" + renderInline(c) + "</th>")
+	}
+	b.WriteString("</tr></thead>\n<tbody>\n")
+
+	i := start + 2
+	for i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != "" {
+		b.WriteString("<tr>")
+		for _, c := range tableCells(lines[i]) {
+			b.WriteString("<td>" + renderInline(c) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+		i++
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return i
+}
+
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+func indentOf(line string) int {
+	n := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// dedent removes up to n columns of leading whitespace.
+func dedent(line string, n int) string {
+	for n > 0 && line != "" {
+		switch line[0] {
+		case ' ':
+			n--
+		case '\t':
+			n -= 4
+		default:
+			return line
+		}
+		line = line[1:]
+	}
+	return line
+}
+
+var (
+	imageRegex     = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	linkRegex      = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	wikiLinkRegex  = regexp.MustCompile(`!?\[\[([^\]|]+)(?:\|([^\]]+))?\]\]`)
+	boldRegex      = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	italicRegex    = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*|\b_(\S(?:.*?\S)?)_\b`)
+	strikeRegex    = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	highlightRegex = regexp.MustCompile(`==(\S(?:.*?\S)?)==`)
+	codeSpanRegex  = regexp.MustCompile("(`+)(.+?)(`+)")
+	tagRefRegex    = regexp.MustCompile("\x00(\\d+)\x00")
+)
+
+// renderInline renders inline formatting. Code spans are kept verbatim.
+func renderInline(s string) string {
+	var b strings.Builder
+	for {
+		loc := codeSpanRegex.FindStringSubmatchIndex(s)
+		if loc == nil || s[loc[2]:loc[3]] != s[loc[6]:loc[7]] {
+			b.WriteString(formatText(s))
+			break
+		}
+		b.WriteString(formatText(s[:loc[0]]))
+		b.WriteString("<code>" + html.EscapeString(strings.TrimSpace(s[loc[4]:loc[5]])) + "</code>")
+		s = s[loc[1]:]
+	}
+	return strings.ReplaceAll(b.String(), "\n", "<br>\n")
+}
+
+// formatText renders links and emphasis in text without code spans.
+// Generated tags with attributes are swapped for placeholders while
+// emphasis is applied, so that "_" or "*" in a URL is left alone.
+func formatText(s string) string {
+	var tags []string
+	protect := func(tag string) string {
+		tags = append(tags, tag)
+		return "\x00" + strconv.Itoa(len(tags)-1) + "\x00"
+	}
+
+	s = html.EscapeString(strings.ReplaceAll(s, "\x00", ""))
+	s = imageRegex.ReplaceAllStringFunc(s, func(m string) string {
+		sub := imageRegex.FindStringSubmatch(m)
+		if !isWebURL(sub[2]) {
+			return `<span class="image">` + sub[1] + `</span>`
+		}
+		return protect(`<img src="` + sub[2] + `" alt="` + sub[1] + `">`)
+	})
+	s = linkRegex.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRegex.FindStringSubmatch(m)
+		if !isWebURL(sub[2]) && !strings.HasPrefix(sub[2], "mailto:") {
+			return sub[1] // Links into the wiki have nowhere to go
+		}
+		return protect(`<a href="`+sub[2]+`">`) + sub[1] + `</a>`
+	})
+	s = wikiLinkRegex.ReplaceAllStringFunc(s, func(m string) string {
+		sub := wikiLinkRegex.FindStringSubmatch(m)
+		text := sub[1]
+		if sub[2] != "" {
+			text = sub[2]
+		}
+		return `<span class="wikilink">` + text + `</span>`
+	})
+	s = boldRegex.ReplaceAllString(s, "<strong>$1$2</strong>")
+	s = italicRegex.ReplaceAllString(s, "<em>$1$2</em>")
+	s = strikeRegex.ReplaceAllString(s, "<del>$1</del>")
+	s = highlightRegex.ReplaceAllString(s, "<mark>$1</mark>")
+	return tagRefRegex.ReplaceAllStringFunc(s, func(m string) string {
+		i, _ := strconv.Atoi(tagRefRegex.FindStringSubmatch(m)[1])
+		return tags[i]
+	})
+}
+
+func isWebURL(u string) bool {
+	return strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://")
+}
@@ -0,0 +1,62 @@
+package markdown
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// Escaping
+		{"raw html is text", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+		{"ampersand", "Q&A", "<p>Q&amp;A</p>\n"},
+		{"quote in link url", `[x](https://e.com/"onmouseover=)`, `<p><a href="https://e.com/&#34;onmouseover=">x</a></p>` + "\n"},
+
+		// Links and images
+		{"link", "[Go](https://go.dev)", `<p><a href="https://go.dev">Go</a></p>` + "\n"},
+		{"mailto link", "[me](mailto:a@b.c)", `<p><a href="mailto:a@b.c">me</a></p>` + "\n"},
+		{"wiki-relative link", "[other](notes/other.md)", "<p>other</p>\n"},
+		{"underscores in url", "[a](https://e.com/snake_case_name) and _b_", `<p><a href="https://e.com/snake_case_name">a</a> and <em>b</em></p>` + "\n"},
+		{"stars in url", "[a](https://e.com/*x*/**y**)", `<p><a href="https://e.com/*x*/**y**">a</a></p>` + "\n"},
+		{"emphasis in link text", "[**Go**](https://go.dev/a_b_c)", `<p><a href="https://go.dev/a_b_c"><strong>Go</strong></a></p>` + "\n"},
+		{"image", "![a_b_c](https://e.com/x_y_z.png)", `<p><img src="https://e.com/x_y_z.png" alt="a_b_c"></p>` + "\n"},
+		{"local image", "![diagram](img/d.png)", `<p><span class="image">diagram</span></p>` + "\n"},
+		{"wikilink", "see [[Pods]] and [[Pods#Init|init]]", `<p>see <span class="wikilink">Pods</span> and <span class="wikilink">init</span></p>` + "\n"},
+		{"placeholder bytes in source", "a\x000\x00b", "<p>a0b</p>\n"},
+
+		// Emphasis
+		{"bold", "**a** and __b__", "<p><strong>a</strong> and <strong>b</strong></p>\n"},
+		{"italic", "*a* and _b_", "<p><em>a</em> and <em>b</em></p>\n"},
+		{"snake case", "snake_case_name", "<p>snake_case_name</p>\n"},
+		{"strike and highlight", "~~old~~ ==new==", "<p><del>old</del> <mark>new</mark></p>\n"},
+		{"code span", "use `a_b *c*` here", "<p>use <code>a_b *c*</code> here</p>\n"},
+		{"line break", "one\ntwo", "<p>one<br>\ntwo</p>\n"},
+
+		// Blocks
+		{"heading", "## Pod *lifecycle* ##", "<h2>Pod <em>lifecycle</em></h2>\n"},
+		{"rule", "---", "<hr>\n"},
+		{"quote", "> a\n> b", "<blockquote>\n<p>a<br>\nb</p>\n</blockquote>\n"},
+		{"table", "| a | b |\n|---|:-:|\n| 1 | *2* |", "<table>\n<thead><tr><th>a</th><th>b</th></tr></thead>\n<tbody>\n<tr><td>1</td><td><em>2</em></td></tr>\n</tbody>\n</table>\n"},
+
+		// Lists
+		{"bullets", "- a\n* b\n+ c", "<ul>\n<li>a</li>\n<li>b</li>\n<li>c</li>\n</ul>\n"},
+		{"ordered", "1. a\n2) b", "<ol>\n<li>a</li>\n<li>b</li>\n</ol>\n"},
+		{"loose list", "- a\n\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"tasks", "- [ ] todo\n- [x] done", "<ul>\n<li><input type=\"checkbox\" disabled> todo</li>\n<li><input type=\"checkbox\" disabled checked> done</li>\n</ul>\n"},
+		{"nested", "- a\n  1. b\n  2. c\n- d", "<ul>\n<li>a\n<ol>\n<li>b</li>\n<li>c</li>\n</ol>\n</li>\n<li>d</li>\n</ul>\n"},
+
+		// Fenced code
+		{"backtick fence", "```go\nif a < b && *c* {\n```", "<pre><code class=\"language-go\">if a &lt; b &amp;&amp; *c* {</code></pre>\n"},
+		{"tilde fence", "~~~\n# not a heading\n```\n~~~\nafter", "<pre><code># not a heading\n```</code></pre>\n<p>after</p>\n"},
+		{"unclosed fence", "```\n- x", "<pre><code>- x</code></pre>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToHTML(tt.in); got != tt.want {
+				t.Errorf("ToHTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
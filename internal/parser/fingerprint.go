@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of consecutive words hashed together.
+const shingleSize = 3
+
+// Fingerprint computes a 64-bit SimHash of text. Similar texts produce
+// fingerprints that differ in few bits, so a renamed note can be matched
+// to its old topic even after small edits. Empty text returns 0.
+func Fingerprint(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	n := max(len(words)-shingleSize+1, 1)
+	for i := 0; i < n; i++ {
+		end := min(i+shingleSize, len(words))
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:end], " ")))
+		sum := h.Sum64()
+		for b := 0; b < 64; b++ {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var fp uint64
+	for b, w := range weights {
+		if w > 0 {
+			fp |= 1 << b
+		}
+	}
+	return fp
+}
+
+// Similarity returns how alike two fingerprints are, from 0 to 1.
+func Similarity(a, b uint64) float64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
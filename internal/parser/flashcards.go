@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flashcard kinds.
+const (
+	CardQA     = "qa"     // "Q: ..." line followed by "A: ..."
+	CardInline = "inline" // "question :: answer"
+	CardCloze  = "cloze"  // "{{c1::hidden}}" deletion
+)
+
+// Flashcard is a question/answer pair extracted from a note body.
+type Flashcard struct {
+	Kind   string
+	Prompt string
+	Answer string
+}
+
+// Matches {{c1::text}} and {{c1::text::hint}}.
+var clozeRegex = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+var listMarkerRegex = regexp.MustCompile(`^\s*(?:[-*+]|\d+\.)\s+`)
+
+// ExtractFlashcards finds Q/A pairs, inline "question :: answer" cards and
+// cloze deletions in markdown text, skipping fenced code blocks.
+func ExtractFlashcards(text string) []Flashcard {
+	var cards []Flashcard
+	var question string
+	var answer []string
+	inAnswer := false
+	var fence codeFence
+
+	flushQA := func() {
+		if question != "" && len(answer) > 0 {
+			cards = append(cards, Flashcard{
+				Kind:   CardQA,
+				Prompt: question,
+				Answer: strings.Join(answer, "\n"),
+			})
+		}
+		question, answer, inAnswer = "", nil, false
+	}
+
+	for _, raw := range strings.Split(text, "\n") {
+		if fence.toggle(raw) {
+			flushQA()
+			continue
+		}
+		if fence.inside() {
+			continue
+		}
+
+		line := strings.TrimSpace(listMarkerRegex.ReplaceAllString(raw, ""))
+
+		if q, ok := cutLabel(line, "Q:"); ok {
+			flushQA()
+			question = q
+			continue
+		}
+		if a, ok := cutLabel(line, "A:"); ok && question != "" {
+			answer = append(answer, a)
+			inAnswer = true
+			continue
+		}
+		if inAnswer {
+			if line == "" || headingRegex.MatchString(raw) {
+				flushQA()
+			} else {
+				answer = append(answer, line)
+				continue
+			}
+		}
+
+		if line == "" {
+			continue
+		}
+		if clozeRegex.MatchString(line) {
+			cards = append(cards, clozeCards(line)...)
+			continue
+		}
+		if q, a, ok := strings.Cut(line, " :: "); ok {
+			q, a = strings.TrimSpace(q), strings.TrimSpace(a)
+			if q != "" && a != "" {
+				cards = append(cards, Flashcard{Kind: CardInline, Prompt: q, Answer: a})
+			}
+		}
+	}
+	flushQA()
+
+	return cards
+}
+
+// clozeCards builds one card per cloze number on a line. The prompt hides
+// that deletion and shows the others as plain text.
+func clozeCards(line string) []Flashcard {
+	seen := make(map[int]bool)
+	var numbers []int
+	for _, m := range clozeRegex.FindAllStringSubmatch(line, -1) {
+		n, _ := strconv.Atoi(m[1]) // The regex only matches digits
+		if !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+
+	answer := clozeRegex.ReplaceAllString(line, "$2")
+
+	cards := make([]Flashcard, 0, len(numbers))
+	for _, n := range numbers {
+		prompt := clozeRegex.ReplaceAllStringFunc(line, func(s string) string {
+			m := clozeRegex.FindStringSubmatch(s)
+			if i, _ := strconv.Atoi(m[1]); i != n {
+				return m[2]
+			}
+			if m[3] != "" {
+				return fmt.Sprintf("[%s]", m[3])
+			}
+			return "[...]"
+		})
+		cards = append(cards, Flashcard{Kind: CardCloze, Prompt: prompt, Answer: answer})
+	}
+	return cards
+}
+
+func cutLabel(line, label string) (string, bool) {
+	if len(line) < len(label) || !strings.EqualFold(line[:len(label)], label) {
+		return "", false
+	}
+	return strings.TrimSpace(line[len(label):]), true
+}
+
+// readBody returns a file's content without its frontmatter.
+func readBody(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	content := string(data)
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		if after, ok := strings.CutPrefix(rest, "---\n"); ok {
+			content = after
+		} else if i := strings.Index(rest, "\n---\n"); i >= 0 {
+			content = rest[i+len("\n---\n"):]
+		} else if strings.HasSuffix(rest, "\n---") {
+			content = ""
+		}
+	}
+	return content, nil
+}
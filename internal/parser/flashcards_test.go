@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractFlashcards(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Flashcard
+	}{
+		{
+			name: "qa",
+			text: "Q: What is a Pod?\nA: The smallest deployable unit",
+			want: []Flashcard{{Kind: CardQA, Prompt: "What is a Pod?", Answer: "The smallest deployable unit"}},
+		},
+		{
+			name: "qa multi-line answer ends at blank line",
+			text: "Q: Pod phases?\nA: Pending\nRunning\n\nNot part of the answer",
+			want: []Flashcard{{Kind: CardQA, Prompt: "Pod phases?", Answer: "Pending\nRunning"}},
+		},
+		{
+			name: "qa in list, lower-case labels",
+			text: "- q: Default port?\n- a: 80",
+			want: []Flashcard{{Kind: CardQA, Prompt: "Default port?", Answer: "80"}},
+		},
+		{
+			name: "question without answer",
+			text: "Q: Unanswered\n\nQ: Answered\nA: yes",
+			want: []Flashcard{{Kind: CardQA, Prompt: "Answered", Answer: "yes"}},
+		},
+		{
+			name: "inline",
+			text: "Default Service type :: ClusterIP\n* In a list :: works",
+			want: []Flashcard{
+				{Kind: CardInline, Prompt: "Default Service type", Answer: "ClusterIP"},
+				{Kind: CardInline, Prompt: "In a list", Answer: "works"},
+			},
+		},
+		{
+			name: "inline needs spaces and both sides",
+			text: "std::vector\n :: no question\nno answer :: ",
+			want: nil,
+		},
+		{
+			name: "cloze with hint",
+			text: "{{c1::etcd::key-value store}} stores state",
+			want: []Flashcard{{Kind: CardCloze, Prompt: "[key-value store] stores state", Answer: "etcd stores state"}},
+		},
+		{
+			name: "cloze one card per number, in numeric order",
+			text: "A {{c10::Deployment}} manages {{c2::ReplicaSets}} of {{c2::Pods}}",
+			want: []Flashcard{
+				{Kind: CardCloze, Prompt: "A Deployment manages [...] of [...]", Answer: "A Deployment manages ReplicaSets of Pods"},
+				{Kind: CardCloze, Prompt: "A [...] manages ReplicaSets of Pods", Answer: "A Deployment manages ReplicaSets of Pods"},
+			},
+		},
+		{
+			name: "fenced code is skipped",
+			text: "```\nQ: not a card\nA: no\nx :: y\n```\nreal :: card",
+			want: []Flashcard{{Kind: CardInline, Prompt: "real", Answer: "card"}},
+		},
+		{
+			name: "tilde fences are skipped and only close with tildes",
+			text: "~~~go\nx :: y\n```\nstill :: code\n~~~\nreal :: card",
+			want: []Flashcard{{Kind: CardInline, Prompt: "real", Answer: "card"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExtractFlashcards(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractFlashcards() =\n%#v\nwant\n%#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanFileFlashcardOwners(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string // Topic title to card prompts
+		warning string
+	}{
+		{
+			name: "marked sections",
+			content: "---\nreview: true\n---\ntop :: file\n" +
+				"## Pods #review\npod :: p\n### Example\nexample :: e\n" +
+				"## Services\nservice :: s\n### Deep #review\ndeep :: d\n",
+			want: map[string][]string{
+				"K":      {"top", "service"},
+				"K#Pods": {"pod", "example"},
+				"K#Deep": {"deep"},
+			},
+		},
+		{
+			name: "sections mode",
+			content: "---\nreview: sections\n---\nfirst :: untracked\n# Title\nintro :: untracked\n" +
+				"## Pods\npod :: p\n### Example\nexample :: e\n#### Detail\ndetail :: d\n",
+			want: map[string][]string{
+				"K#Pods":    {"pod"},
+				"K#Example": {"example", "detail"},
+			},
+			warning: "2 flashcards are outside any H2/H3 section and not tracked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeNote(t, "K.md", tt.content)
+			topics, warnings, err := ScanFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var warning string
+			for _, w := range warnings {
+				if w.File != path {
+					t.Errorf("warning for %s, want %s", w.File, path)
+				}
+				warning += w.Message
+			}
+			if warning != tt.warning {
+				t.Errorf("warnings = %q, want %q", warning, tt.warning)
+			}
+
+			got := make(map[string][]string)
+			for _, topic := range topics {
+				var prompts []string
+				for _, c := range topic.Flashcards {
+					prompts = append(prompts, c.Prompt)
+				}
+				got[topic.Title] = prompts
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cards by topic = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Field is a top-level frontmatter key and its scalar value.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// SetFrontmatterFields sets top-level scalar keys in a file's frontmatter.
+// Existing lines for those keys are replaced in place and new keys are
+// appended before the closing ---. All other bytes of the file, including
+// the body and line endings, are preserved. The file is replaced
+// atomically, so a crash never leaves a truncated note.
+func SetFrontmatterFields(filePath string, fields []Field) error {
+	// Write through symlinks instead of replacing them.
+	filePath, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	newline := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], "\r\n") != "---" {
+		return fmt.Errorf("no frontmatter in %s", filePath)
+	}
+
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimRight(lines[i], "\r\n") == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return fmt.Errorf("unterminated frontmatter in %s", filePath)
+	}
+
+	out := make([]string, 0, len(lines)+len(fields))
+	out = append(out, lines[0])
+
+	written := make(map[string]bool)
+	for i := 1; i < end; i++ {
+		key, ok := topLevelKey(lines[i])
+		field := findField(fields, key)
+		if !ok || field == nil {
+			out = append(out, lines[i])
+			continue
+		}
+
+		line, err := formatField(*field, newline)
+		if err != nil {
+			return err
+		}
+		out = append(out, line)
+		written[key] = true
+
+		// Drop the old value's continuation lines (block lists/maps).
+		for i+1 < end && isContinuation(lines[i+1]) {
+			i++
+		}
+	}
+
+	for _, f := range fields {
+		if written[f.Key] {
+			continue
+		}
+		line, err := formatField(f, newline)
+		if err != nil {
+			return err
+		}
+		out = append(out, line)
+	}
+
+	out = append(out, lines[end:]...)
+
+	updated := strings.Join(out, "")
+	if updated == string(data) {
+		return nil
+	}
+	return writeFileAtomic(filePath, []byte(updated), info.Mode().Perm())
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory
+// and renames it over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func topLevelKey(line string) (string, bool) {
+	if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+		return "", false
+	}
+	key, _, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(key), true
+}
+
+func isContinuation(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "- ")
+}
+
+func findField(fields []Field, key string) *Field {
+	for i := range fields {
+		if fields[i].Key == key {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func formatField(f Field, newline string) (string, error) {
+	out, err := yaml.Marshal(map[string]any{f.Key: f.Value})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n") + newline, nil
+}
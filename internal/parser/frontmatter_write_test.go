@@ -0,0 +1,143 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSetFrontmatterFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		fields  []Field
+		want    string
+	}{
+		{
+			name:    "replace in place",
+			content: "---\nreview: true\nrecall_due: 2026-01-01\ntitle: Pods\n---\nbody\n",
+			fields:  []Field{{"recall_due", "2026-02-01"}},
+			want:    "---\nreview: true\nrecall_due: \"2026-02-01\"\ntitle: Pods\n---\nbody\n",
+		},
+		{
+			name:    "append new keys before the closing line",
+			content: "---\nreview: true\n---\nbody\n",
+			fields:  []Field{{"recall_state", "review"}, {"recall_stability", 12.5}},
+			want:    "---\nreview: true\nrecall_state: review\nrecall_stability: 12.5\n---\nbody\n",
+		},
+		{
+			name:    "replace a block list",
+			content: "---\nrecall_state:\n  - old\n  - older\n- unindented\nreview: true\n---\n",
+			fields:  []Field{{"recall_state", "learning"}},
+			want:    "---\nrecall_state: learning\nreview: true\n---\n",
+		},
+		{
+			name:    "keep CRLF line endings",
+			content: "---\r\nreview: true\r\nrecall_due: 2026-01-01\r\n---\r\nbody\r\n",
+			fields:  []Field{{"recall_due", "2026-02-01"}, {"recall_state", "review"}},
+			want:    "---\r\nreview: true\r\nrecall_due: \"2026-02-01\"\r\nrecall_state: review\r\n---\r\nbody\r\n",
+		},
+		{
+			name:    "body dashes are not frontmatter",
+			content: "---\nreview: true\n---\nrecall_due: 1\n---\n",
+			fields:  []Field{{"recall_due", 2}},
+			want:    "---\nreview: true\nrecall_due: 2\n---\nrecall_due: 1\n---\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeNote(t, "note.md", tt.content)
+			if err := SetFrontmatterFields(path, tt.fields); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFrontmatterFieldsNoChange(t *testing.T) {
+	// As written by a previous sync, so syncing again changes nothing.
+	content := "---\r\nreview: true\r\nrecall_due: \"2026-02-01\"\r\nrecall_state: review\r\n---\r\nbody"
+	path := writeNote(t, "note.md", content)
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetFrontmatterFields(path, []Field{{"recall_due", "2026-02-01"}, {"recall_state", "review"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("file was rewritten: mtime %v, want %v", info.ModTime(), old)
+	}
+	if got, _ := os.ReadFile(path); string(got) != content {
+		t.Errorf("got %q, want %q", got, content)
+	}
+}
+
+func TestSetFrontmatterFieldsReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "note.md")
+	if err := os.WriteFile(target, []byte("---\nreview: true\n---\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.md")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	if err := SetFrontmatterFields(link, []Field{{"uid", "abc"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("symlink was replaced: %v", err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+	if got, _ := os.ReadFile(target); string(got) != "---\nreview: true\nuid: abc\n---\n" {
+		t.Errorf("got %q", got)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestSetFrontmatterFieldsErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"no frontmatter": "# Title\n",
+		"unterminated":   "---\nreview: true\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeNote(t, "note.md", content)
+			if err := SetFrontmatterFields(path, []Field{{"recall_state", "review"}}); err == nil {
+				t.Error("expected an error")
+			}
+			if got, _ := os.ReadFile(path); string(got) != content {
+				t.Errorf("file changed to %q", got)
+			}
+		})
+	}
+}
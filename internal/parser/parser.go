@@ -2,6 +2,7 @@ package parser
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -11,16 +12,78 @@ import (
 )
 
 type ParsedTopic struct {
-	Title string
-	File  string
-	Tags  []string
+	Title   string
+	File    string
+	Heading string // Section key for section topics, empty for whole-file topics
+	UID     string // Recall-written uid from frontmatter, if any
+	Tags    []string
+
+	Suspended bool // Frontmatter says "review: suspended"
+
+	Fingerprint uint64 // SimHash of the topic's content
+	Flashcards  []Flashcard
+}
+
+// Warning is a problem in a note that does not stop the scan.
+type Warning struct {
+	File    string
+	Message string
 }
 
 // Frontmatter represents the YAML frontmatter structure
 type Frontmatter struct {
-	ID     string   `yaml:"id"`
-	Tags   []string `yaml:"tags"`
-	Review bool     `yaml:"review"`
+	ID     string     `yaml:"id"`
+	UID    string     `yaml:"uid"`
+	Tags   []string   `yaml:"tags"`
+	Review ReviewMode `yaml:"review"`
+
+	// Scheduling written by the Obsidian Spaced Repetition plugin, kept
+	// as text and parsed by 'recall import obsidian-sr'.
+	SRDue      string `yaml:"sr-due"`      // YYYY-MM-DD
+	SRInterval string `yaml:"sr-interval"` // Days
+	SREase     string `yaml:"sr-ease"`     // Percent, 250 to start
+
+	// Schedule written by recall when sync_frontmatter is on.
+	RecallDue        string `yaml:"recall_due"` // YYYY-MM-DD
+	RecallState      string `yaml:"recall_state"`
+	RecallStability  string `yaml:"recall_stability"`
+	RecallDifficulty string `yaml:"recall_difficulty"`
+	RecallLastReview string `yaml:"recall_last_review"` // RFC 3339
+}
+
+// ReviewMode is the value of the frontmatter review field.
+type ReviewMode string
+
+const (
+	ReviewOff       ReviewMode = ""
+	ReviewOn        ReviewMode = "true"
+	ReviewSections  ReviewMode = "sections"  // Every H2/H3 is its own topic
+	ReviewSuspended ReviewMode = "suspended" // Tracked but not scheduled
+)
+
+// UnmarshalYAML accepts both booleans and mode names.
+func (m *ReviewMode) UnmarshalYAML(value *yaml.Node) error {
+	var b bool
+	if err := value.Decode(&b); err == nil {
+		if b {
+			*m = ReviewOn
+		} else {
+			*m = ReviewOff
+		}
+		return nil
+	}
+
+	var str string
+	if err := value.Decode(&str); err != nil {
+		return err
+	}
+	switch mode := ReviewMode(strings.ToLower(strings.TrimSpace(str))); mode {
+	case ReviewSections, ReviewSuspended:
+		*m = mode
+	default:
+		*m = ReviewOff
+	}
+	return nil
 }
 
 var headingRegex = regexp.MustCompile(`^(#{1,6})\s+(.+)$`)
@@ -57,6 +120,18 @@ func parseFrontmatter(scanner *bufio.Scanner) (*Frontmatter, error) {
 	return &fm, nil
 }
 
+// ReadFrontmatter parses a file's frontmatter. It returns nil if the file
+// has none.
+func ReadFrontmatter(filePath string) (*Frontmatter, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseFrontmatter(bufio.NewScanner(file))
+}
+
 // findFirstHeading scans remaining content for first heading
 func findFirstHeading(scanner *bufio.Scanner) string {
 	for scanner.Scan() {
@@ -75,10 +150,12 @@ func getTitleFromFilename(filePath string) string {
 	return strings.TrimSuffix(base, ".md")
 }
 
-func ScanFile(filePath string) ([]ParsedTopic, error) {
+// ScanFile returns the topics tracked in a note, with warnings about
+// content that belongs to none of them.
+func ScanFile(filePath string) ([]ParsedTopic, []Warning, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
@@ -87,12 +164,12 @@ func ScanFile(filePath string) ([]ParsedTopic, error) {
 	// Parse frontmatter
 	fm, err := parseFrontmatter(scanner)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// If no frontmatter or review is not true, skip this file
-	if fm == nil || !fm.Review {
-		return nil, nil
+	// If no frontmatter or review is not set, skip this file
+	if fm == nil || fm.Review == ReviewOff {
+		return nil, nil, nil
 	}
 
 	// Use id from frontmatter, fallback to filename, then first heading
@@ -104,17 +181,93 @@ func ScanFile(filePath string) ([]ParsedTopic, error) {
 		title = findFirstHeading(scanner)
 	}
 
-	topic := ParsedTopic{
-		Title: title,
-		File:  filePath,
-		Tags:  fm.Tags,
+	preamble, sections, err := readSections(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var topics []ParsedTopic
+	fileTopic := -1
+
+	// In sections mode the file itself is not a topic, only its H2/H3
+	// headings. Otherwise headings with a review marker are added
+	// alongside the whole-file topic.
+	if fm.Review != ReviewSections {
+		body, err := readBody(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		fileTopic = len(topics)
+		topics = append(topics, ParsedTopic{
+			Title:       title,
+			File:        filePath,
+			UID:         fm.UID,
+			Tags:        fm.Tags,
+			Suspended:   fm.Review == ReviewSuspended,
+			Fingerprint: Fingerprint(body),
+			Flashcards:  ExtractFlashcards(preamble),
+		})
+	}
+
+	// owner maps each section to the topic its own text belongs to: its
+	// own topic if tracked, else the nearest tracked enclosing section's,
+	// else the whole file's.
+	owner := make([]int, len(sections))
+	for i, sec := range sections {
+		owner[i] = fileTopic
+		if sec.Parent >= 0 {
+			owner[i] = owner[sec.Parent]
+		}
+
+		inMode := fm.Review == ReviewSections && (sec.Level == 2 || sec.Level == 3)
+		if !inMode && !sec.Marked {
+			continue
+		}
+		owner[i] = len(topics)
+		topics = append(topics, ParsedTopic{
+			Title:       SectionTitle(title, sec.Key),
+			File:        filePath,
+			Heading:     sec.Key,
+			UID:         fm.UID,
+			Tags:        fm.Tags,
+			Suspended:   fm.Review == ReviewSuspended,
+			Fingerprint: Fingerprint(sec.Body),
+		})
 	}
 
-	return []ParsedTopic{topic}, nil
+	// Each flashcard belongs to one topic only, so a card under an H3 is
+	// not repeated by its H2 or the whole file. In sections mode, cards
+	// outside every H2/H3 belong to no topic.
+	var untracked int
+	if fileTopic < 0 {
+		untracked = len(ExtractFlashcards(preamble))
+	}
+	for i, sec := range sections {
+		cards := ExtractFlashcards(sec.Text)
+		if owner[i] < 0 {
+			untracked += len(cards)
+			continue
+		}
+		topics[owner[i]].Flashcards = append(topics[owner[i]].Flashcards, cards...)
+	}
+
+	var warnings []Warning
+	if untracked > 0 {
+		cards := fmt.Sprintf("%d flashcards are", untracked)
+		if untracked == 1 {
+			cards = "1 flashcard is"
+		}
+		warnings = append(warnings, Warning{
+			File:    filePath,
+			Message: cards + " outside any H2/H3 section and not tracked",
+		})
+	}
+	return topics, warnings, nil
 }
 
-func ScanDirectory(dir string) ([]ParsedTopic, error) {
+func ScanDirectory(dir string) ([]ParsedTopic, []Warning, error) {
 	var allTopics []ParsedTopic
+	var allWarnings []Warning
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -128,15 +281,16 @@ func ScanDirectory(dir string) ([]ParsedTopic, error) {
 
 		// Only process .md files
 		if !info.IsDir() && strings.HasSuffix(info.Name(), ".md") {
-			topics, err := ScanFile(path)
+			topics, warnings, err := ScanFile(path)
 			if err != nil {
 				return err
 			}
 			allTopics = append(allTopics, topics...)
+			allWarnings = append(allWarnings, warnings...)
 		}
 
 		return nil
 	})
 
-	return allTopics, err
+	return allTopics, allWarnings, err
 }
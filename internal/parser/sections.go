@@ -0,0 +1,241 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Section is a heading and the content beneath it, up to the next heading
+// of the same or a higher level.
+type Section struct {
+	Heading string
+	Key     string // Heading, qualified by parent headings when not unique; see sectionKeys
+	Level   int
+	Line    int  // 1-based line number of the heading
+	Marked  bool // Heading carries a review marker
+	Parent  int  // Index of the enclosing section, -1 if there is none
+	Body    string
+	Text    string // Body up to the first subheading
+}
+
+// A heading is marked for review with a trailing "#review" tag or
+// "<!-- review -->" comment, e.g. "## Pods #review".
+var reviewMarkerRegex = regexp.MustCompile(`\s*(#review|<!--\s*review\s*-->)\s*$`)
+
+// ReadSections returns every heading in a markdown file (outside the
+// frontmatter and fenced code blocks) along with its body.
+func ReadSections(filePath string) ([]Section, error) {
+	_, sections, err := readSections(filePath)
+	return sections, err
+}
+
+// readSections is ReadSections that also returns the text before the
+// first heading.
+func readSections(filePath string) (string, []Section, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	var sections []Section
+	var starts []int // Index into lines of each section's heading
+	inFrontmatter := false
+	var fence codeFence
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+
+		if lineNo == 1 && line == "---" {
+			inFrontmatter = true
+			continue
+		}
+		if inFrontmatter {
+			if line == "---" {
+				inFrontmatter = false
+			}
+			continue
+		}
+
+		fence.toggle(line)
+
+		if !fence.inside() {
+			if matches := headingRegex.FindStringSubmatch(line); matches != nil {
+				heading, marked := stripReviewMarker(matches[2])
+				sections = append(sections, Section{
+					Heading: heading,
+					Level:   len(matches[1]),
+					Line:    lineNo,
+					Marked:  marked,
+				})
+				starts = append(starts, len(lines))
+			}
+		}
+
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, err
+	}
+
+	for i := range sections {
+		end, textEnd := len(lines), len(lines)
+		if i+1 < len(sections) {
+			textEnd = starts[i+1]
+		}
+		for j := i + 1; j < len(sections); j++ {
+			if sections[j].Level <= sections[i].Level {
+				end = starts[j]
+				break
+			}
+		}
+		sections[i].Body = strings.TrimSpace(strings.Join(lines[starts[i]+1:end], "\n"))
+		sections[i].Text = strings.TrimSpace(strings.Join(lines[starts[i]+1:textEnd], "\n"))
+
+		// The nearest earlier section of a lower level encloses this one.
+		sections[i].Parent = -1
+		for j := i - 1; j >= 0; j-- {
+			if sections[j].Level < sections[i].Level {
+				sections[i].Parent = j
+				break
+			}
+		}
+	}
+	sectionKeys(sections)
+
+	preamble := lines
+	if len(starts) > 0 {
+		preamble = lines[:starts[0]]
+	}
+	return strings.TrimSpace(strings.Join(preamble, "\n")), sections, nil
+}
+
+// sectionKeys sets each section's Key to its heading, prefixed with as many
+// parent headings as it takes to tell it apart from other sections with
+// the same heading, e.g. "Pods#Example" and "Services#Example". Sections
+// with a unique heading are keyed by the heading alone, and repeated full
+// paths get an ordinal.
+func sectionKeys(sections []Section) {
+	paths := make([][]string, len(sections))
+	for i, sec := range sections {
+		path := []string{sec.Heading}
+		for j := sec.Parent; j >= 0; j = sections[j].Parent {
+			path = append([]string{sections[j].Heading}, path...)
+		}
+		paths[i] = path
+	}
+
+	suffix := func(path []string, n int) string {
+		return strings.Join(path[max(len(path)-n, 0):], "#")
+	}
+	for i, path := range paths {
+		for n := 1; ; n++ {
+			key := suffix(path, n)
+			unique := true
+			for j, other := range paths {
+				if j != i && strings.EqualFold(suffix(other, n), key) {
+					unique = false
+					break
+				}
+			}
+			if unique || n >= len(path) {
+				sections[i].Key = key
+				break
+			}
+		}
+	}
+
+	// Sections with the same full path are numbered in order, e.g.
+	// "Pods#Example (2)", skipping keys that are taken by real headings.
+	used := make(map[string]bool)
+	for _, sec := range sections {
+		used[strings.ToLower(sec.Key)] = true
+	}
+	seen := make(map[string]int)
+	for i, sec := range sections {
+		base := strings.ToLower(sec.Key)
+		seen[base]++
+		if seen[base] == 1 {
+			continue
+		}
+		for n := seen[base]; ; n++ {
+			key := fmt.Sprintf("%s (%d)", sec.Key, n)
+			if !used[strings.ToLower(key)] {
+				sections[i].Key = key
+				used[strings.ToLower(key)] = true
+				break
+			}
+		}
+	}
+}
+
+// codeFence tracks fenced code blocks. A block opened with ``` or ~~~ is
+// only closed by the same kind of fence.
+type codeFence struct {
+	open string
+}
+
+// toggle updates the state for line and reports whether it is a fence.
+func (f *codeFence) toggle(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, marker := range []string{"```", "~~~"} {
+		if !strings.HasPrefix(trimmed, marker) {
+			continue
+		}
+		switch f.open {
+		case "":
+			f.open = marker
+			return true
+		case marker:
+			f.open = ""
+			return true
+		}
+	}
+	return false
+}
+
+func (f *codeFence) inside() bool {
+	return f.open != ""
+}
+
+// FindSection returns the section whose key matches (case-insensitive),
+// or nil if there is none. A plain heading that is not unique matches its
+// first section.
+func FindSection(filePath, key string) (*Section, error) {
+	sections, err := ReadSections(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	key = strings.TrimSpace(key)
+	for i := range sections {
+		if strings.EqualFold(sections[i].Key, key) {
+			return &sections[i], nil
+		}
+	}
+	for i := range sections {
+		if strings.EqualFold(sections[i].Heading, key) {
+			return &sections[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// SectionTitle builds the topic title for a section from its key, e.g.
+// "Kubernetes#Pods" or "Kubernetes#Pods#Example".
+func SectionTitle(fileTitle, key string) string {
+	return fileTitle + "#" + key
+}
+
+func stripReviewMarker(heading string) (string, bool) {
+	heading = strings.TrimSpace(heading)
+	if !reviewMarkerRegex.MatchString(heading) {
+		return heading, false
+	}
+	return strings.TrimSpace(reviewMarkerRegex.ReplaceAllString(heading, "")), true
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeNote writes a note into a temporary directory and returns its path.
+func writeNote(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadSections(t *testing.T) {
+	content := "---\ntitle: # not a heading\n---\nintro\n" +
+		"# Kubernetes\nabout\n" +
+		"## Pods #review\npods\n" +
+		"```\n## Not a heading\n~~~\n```\n" +
+		"### Example\npod example\n" +
+		"## Services <!-- review -->\nservices\n" +
+		"### Example\nservice example\n"
+
+	type section struct {
+		Heading, Key string
+		Level, Line  int
+		Marked       bool
+		Parent       int
+		Body, Text   string
+	}
+	want := []section{
+		{"Kubernetes", "Kubernetes", 1, 5, false, -1,
+			"about\n## Pods #review\npods\n```\n## Not a heading\n~~~\n```\n### Example\npod example\n## Services <!-- review -->\nservices\n### Example\nservice example",
+			"about"},
+		{"Pods", "Pods", 2, 7, true, 0,
+			"pods\n```\n## Not a heading\n~~~\n```\n### Example\npod example",
+			"pods\n```\n## Not a heading\n~~~\n```"},
+		{"Example", "Pods#Example", 3, 13, false, 1, "pod example", "pod example"},
+		{"Services", "Services", 2, 15, true, 0,
+			"services\n### Example\nservice example", "services"},
+		{"Example", "Services#Example", 3, 17, false, 3, "service example", "service example"},
+	}
+
+	preamble, sections, err := readSections(writeNote(t, "K.md", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preamble != "intro" {
+		t.Errorf("preamble = %q, want %q", preamble, "intro")
+	}
+	var got []section
+	for _, s := range sections {
+		got = append(got, section{s.Heading, s.Key, s.Level, s.Line, s.Marked, s.Parent, s.Body, s.Text})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readSections() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSectionKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []Section
+		want     []string
+	}{
+		{
+			name:     "unique headings are not qualified",
+			sections: []Section{{Heading: "A", Parent: -1}, {Heading: "B", Parent: 0}},
+			want:     []string{"A", "B"},
+		},
+		{
+			name: "repeated heading takes its parent",
+			sections: []Section{
+				{Heading: "Title", Parent: -1},
+				{Heading: "Pods", Parent: 0}, {Heading: "Example", Parent: 1},
+				{Heading: "Services", Parent: 0}, {Heading: "Example", Parent: 3},
+			},
+			want: []string{"Title", "Pods", "Pods#Example", "Services", "Services#Example"},
+		},
+		{
+			name: "only as many parents as needed",
+			sections: []Section{
+				{Heading: "A", Parent: -1}, {Heading: "X", Parent: 0}, {Heading: "Z", Parent: 1},
+				{Heading: "B", Parent: -1}, {Heading: "X", Parent: 3}, {Heading: "Z", Parent: 4},
+			},
+			want: []string{"A", "A#X", "A#X#Z", "B", "B#X", "B#X#Z"},
+		},
+		{
+			name:     "identical paths are numbered",
+			sections: []Section{{Heading: "Same", Parent: -1}, {Heading: "same", Parent: -1}, {Heading: "Same", Parent: -1}},
+			want:     []string{"Same", "same (2)", "Same (3)"},
+		},
+		{
+			name: "numbers skip real headings",
+			sections: []Section{
+				{Heading: "X", Parent: -1}, {Heading: "X (2)", Parent: -1}, {Heading: "X", Parent: -1},
+			},
+			want: []string{"X", "X (2)", "X (3)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sectionKeys(tt.sections)
+			var got []string
+			for _, s := range tt.sections {
+				got = append(got, s.Key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSection(t *testing.T) {
+	path := writeNote(t, "K.md", "## Pods\n### Example\npod\n## Services\n### Example\nservice\n")
+
+	tests := []struct {
+		key  string
+		want string // Body, empty if not found
+	}{
+		{"Services#Example", "service"},
+		{"pods#example", "pod"},
+		{" Pods ", "### Example\npod"},
+		{"Example", "pod"}, // Unqualified repeated heading: first match
+		{"Missing", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			sec, err := FindSection(path, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if sec != nil {
+				got = sec.Body
+			}
+			if got != tt.want {
+				t.Errorf("FindSection(%q) body = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanFileSections(t *testing.T) {
+	content := "---\nreview: sections\n---\n## Pods\n### Example\npod\n## Services\n### Example\nservice\n## Pods\nagain\n"
+	topics, _, err := ScanFile(writeNote(t, "K.md", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got [][2]string
+	for _, topic := range topics {
+		got = append(got, [2]string{topic.Title, topic.Heading})
+	}
+	want := [][2]string{
+		{"K#Pods", "Pods"},
+		{"K#Pods#Example", "Pods#Example"},
+		{"K#Services", "Services"},
+		{"K#Services#Example", "Services#Example"},
+		{"K#Pods (2)", "Pods (2)"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("topics = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,198 @@
+// Package stats summarizes review history and card state for 'recall stats'.
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	"github.com/amiraminb/recall/internal/storage"
+)
+
+// MatureInterval is the scheduled interval, in days, from which a card
+// counts as mature rather than young.
+const MatureInterval = 21
+
+// Retention is the share of recall reviews (reviews and relearns) that
+// were not rated Again.
+type Retention struct {
+	Key     string // Month ("2006-01") or tag
+	Reviews int
+	Passed  int
+}
+
+// Rate returns the retention rate, or 0 without reviews.
+func (r Retention) Rate() float64 {
+	if r.Reviews == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Reviews)
+}
+
+// Stats is a snapshot of how learning is going.
+type Stats struct {
+	ByMonth []Retention // Oldest month first
+	ByTag   []Retention // Sorted by tag
+	Overall Retention
+
+	// DailyReviews counts every review, first reads included, for each of
+	// the last N days, oldest first and ending today.
+	DailyReviews  []int
+	CurrentStreak int // Consecutive days with reviews, ending today or yesterday
+	LongestStreak int
+
+	AvgStability  float64 // Over topics that have been read
+	AvgDifficulty float64
+
+	LapseLeaders []storage.Topic // Most lapses first
+
+	TopicStates map[fsrs.State]int
+	CardStates  map[fsrs.State]int // Flashcards
+
+	Mature int // Topics with an interval of at least MatureInterval days
+	Young  int // Read topics with a shorter interval
+}
+
+// Compute builds statistics from all stored data, with days as defined
+// by clock. Archived topics only count towards review history. days is
+// how many days DailyReviews covers and leaders caps LapseLeaders.
+func Compute(data *storage.Data, clock fsrs.Clock, now time.Time, days, leaders int) *Stats {
+	s := &Stats{
+		TopicStates:  make(map[fsrs.State]int),
+		CardStates:   make(map[fsrs.State]int),
+		DailyReviews: make([]int, days),
+	}
+
+	tags := make(map[string][]string)
+	for _, t := range data.Topics {
+		tags[t.ID] = t.Tags
+	}
+
+	reviews := make([]storage.ReviewLog, len(data.Reviews))
+	copy(reviews, data.Reviews)
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].ReviewedAt.Before(reviews[j].ReviewedAt)
+	})
+
+	months := make(map[string]*Retention)
+	byTag := make(map[string]*Retention)
+	reviewDays := make(map[time.Time]bool)
+	today := clock.StartOfDay(now)
+
+	for _, r := range reviews {
+		if r.Kind == storage.KindReschedule {
+			continue
+		}
+		day := clock.StartOfDay(r.ReviewedAt)
+		reviewDays[day] = true
+		if i := days - 1 - clock.Days(day, today); i >= 0 && i < days {
+			s.DailyReviews[i]++
+		}
+
+		// First reads do not test recall.
+		if r.Kind == storage.KindRead {
+			continue
+		}
+
+		passed := r.Rating != fsrs.Again
+		month := day.Format("2006-01")
+		if months[month] == nil {
+			months[month] = &Retention{Key: month}
+		}
+		months[month].add(passed)
+		s.Overall.add(passed)
+		for _, tag := range tags[r.TopicID] {
+			if byTag[tag] == nil {
+				byTag[tag] = &Retention{Key: tag}
+			}
+			byTag[tag].add(passed)
+		}
+	}
+
+	s.ByMonth = sortedRetention(months)
+	s.ByTag = sortedRetention(byTag)
+	s.CurrentStreak, s.LongestStreak = streaks(clock, reviewDays, today)
+
+	var read int
+	for _, t := range data.Topics {
+		if !t.IsActive() {
+			continue
+		}
+		s.TopicStates[t.Card.State]++
+		if t.Card.State == fsrs.New {
+			continue
+		}
+
+		read++
+		s.AvgStability += t.Card.Stability
+		s.AvgDifficulty += t.Card.Difficulty
+		if clock.Days(t.Card.LastReview, t.Card.Due) >= MatureInterval {
+			s.Mature++
+		} else {
+			s.Young++
+		}
+		if t.Card.Lapses > 0 {
+			s.LapseLeaders = append(s.LapseLeaders, t)
+		}
+	}
+	if read > 0 {
+		s.AvgStability /= float64(read)
+		s.AvgDifficulty /= float64(read)
+	}
+
+	sort.SliceStable(s.LapseLeaders, func(i, j int) bool {
+		return s.LapseLeaders[i].Card.Lapses > s.LapseLeaders[j].Card.Lapses
+	})
+	if len(s.LapseLeaders) > leaders {
+		s.LapseLeaders = s.LapseLeaders[:leaders]
+	}
+
+	for _, c := range data.Flashcards {
+		s.CardStates[c.Card.State]++
+	}
+
+	return s
+}
+
+func (r *Retention) add(passed bool) {
+	r.Reviews++
+	if passed {
+		r.Passed++
+	}
+}
+
+func sortedRetention(m map[string]*Retention) []Retention {
+	result := make([]Retention, 0, len(m))
+	for _, r := range m {
+		result = append(result, *r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// streaks returns the run of review days ending today (or yesterday, so
+// the streak survives until today's reviews are done) and the longest run.
+func streaks(clock fsrs.Clock, reviewDays map[time.Time]bool, today time.Time) (current, longest int) {
+	days := make([]time.Time, 0, len(reviewDays))
+	for d := range reviewDays {
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	run := 0
+	for i, d := range days {
+		if i > 0 && clock.Days(days[i-1], d) == 1 {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+
+	if len(days) > 0 && clock.Days(days[len(days)-1], today) <= 1 {
+		current = run
+	}
+	return current, longest
+}
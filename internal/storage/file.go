@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// maxBackups is how many rotating backups are kept in .srs/backups.
+	maxBackups = 10
+	// backupInterval is the minimum time between two backups.
+	backupInterval = time.Hour
+)
+
+// save writes the data atomically: it is written to a temp file in the
+// same directory, synced, and renamed over the data file, so a crash
+// leaves either the old or the new file but never a partial one.
+func (s *JSONStorage) save() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := s.backup(); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data, 0o644)
+}
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// backup copies the current data file into .srs/backups when the newest
+// backup is older than backupInterval, keeping the last maxBackups.
+func (s *JSONStorage) backup() error {
+	current, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(filepath.Dir(s.path), "backups")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	backups, err := listBackups(dir)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		info, err := os.Stat(backups[len(backups)-1])
+		if err == nil && time.Since(info.ModTime()) < backupInterval {
+			return nil
+		}
+	}
+
+	name := "reviews-" + time.Now().Format("20060102-150405") + ".json"
+	if err := writeFileAtomic(filepath.Join(dir, name), current, 0o644); err != nil {
+		return err
+	}
+
+	backups, err = listBackups(dir)
+	if err != nil {
+		return err
+	}
+	for len(backups) > maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// listBackups returns backup paths, oldest first.
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "reviews-") && strings.HasSuffix(e.Name(), ".json") {
+			paths = append(paths, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+)
+
+// SyncFlashcards makes a topic's flashcards match cards. Cards are matched
+// by prompt, so an edited answer keeps its schedule while an edited prompt
+// starts over as a new card.
+func (s *JSONStorage) SyncFlashcards(topicID string, cards []Flashcard) (added, removed int, err error) {
+	existing := make(map[string]bool)
+	changed := false
+
+	for _, c := range cards {
+		id := flashcardID(topicID, c.Kind, c.Prompt)
+		if existing[id] {
+			continue
+		}
+		existing[id] = true
+
+		if fc := s.GetFlashcard(id); fc != nil {
+			if fc.Answer != c.Answer {
+				fc.Answer = c.Answer
+				changed = true
+			}
+			continue
+		}
+
+		s.data.Flashcards = append(s.data.Flashcards, Flashcard{
+			ID:      id,
+			TopicID: topicID,
+			Kind:    c.Kind,
+			Prompt:  c.Prompt,
+			Answer:  c.Answer,
+			Card:    fsrs.NewCard(),
+			Created: time.Now(),
+		})
+		added++
+	}
+
+	before := len(s.data.Flashcards)
+	s.data.Flashcards = slices.DeleteFunc(s.data.Flashcards, func(c Flashcard) bool {
+		return c.TopicID == topicID && !existing[c.ID]
+	})
+	removed = before - len(s.data.Flashcards)
+
+	if added == 0 && removed == 0 && !changed {
+		return 0, 0, nil
+	}
+	s.dirty = true
+	return added, removed, nil
+}
+
+func (s *JSONStorage) GetFlashcard(id string) *Flashcard {
+	for i := range s.data.Flashcards {
+		if s.data.Flashcards[i].ID == id {
+			return &s.data.Flashcards[i]
+		}
+	}
+	return nil
+}
+
+func (s *JSONStorage) GetFlashcards(topicID string) []Flashcard {
+	var cards []Flashcard
+	for _, c := range s.data.Flashcards {
+		if c.TopicID == topicID {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
+
+// GetDueFlashcards returns flashcards of active, unburied topics due by
+// until.
+func (s *JSONStorage) GetDueFlashcards(until time.Time) []Flashcard {
+	var due []Flashcard
+	for _, c := range s.data.Flashcards {
+		if t := s.GetTopic(c.TopicID); t == nil || !t.IsActive() || t.IsBuried(until) {
+			continue
+		}
+		if !c.Card.Due.After(until) {
+			due = append(due, c)
+		}
+	}
+	return due
+}
+
+func (s *JSONStorage) UpdateFlashcard(card *Flashcard) error {
+	for i := range s.data.Flashcards {
+		if s.data.Flashcards[i].ID == card.ID {
+			s.data.Flashcards[i] = *card
+			s.dirty = true
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,367 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+)
+
+// JSONStorage keeps all data in a single JSON file, loaded into memory.
+//
+// Reads work on the snapshot loaded when the storage was opened. Changes
+// must be made inside Update, which locks the file against other recall
+// processes, reloads it, and saves once when done.
+type JSONStorage struct {
+	path  string
+	data  *Data
+	dirty bool
+}
+
+func NewJSONStorage(wikiPath string) (*JSONStorage, error) {
+	srsDir := filepath.Join(wikiPath, ".srs")
+	if err := os.MkdirAll(srsDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &JSONStorage{
+		path: filepath.Join(srsDir, "reviews.json"),
+	}
+
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+	if s.dirty {
+		// Save the migrated data; Update migrates again after reloading.
+		if err := s.Update(func() error { return nil }); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *JSONStorage) Load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.data = NewData()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.data = &Data{}
+	if err := json.Unmarshal(data, s.data); err != nil {
+		return err
+	}
+	if migrateData(s.data) {
+		s.dirty = true
+	}
+	return nil
+}
+
+func (s *JSONStorage) Close() error {
+	return nil
+}
+
+// Export returns all stored data.
+func (s *JSONStorage) Export() (*Data, error) {
+	return s.data, nil
+}
+
+// Import replaces all stored data. It must be called inside Update.
+func (s *JSONStorage) Import(data *Data) error {
+	data.Version = DataVersion
+	s.data = data
+	s.dirty = true
+	return nil
+}
+
+// Update runs fn with the data file locked and freshly reloaded, so
+// changes made by other recall processes are not lost. If fn succeeds
+// and changed anything, the data is saved once; if it fails, the
+// in-memory changes are discarded.
+func (s *JSONStorage) Update(fn func() error) error {
+	unlock, err := lockFile(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.dirty = false
+	if err := s.Load(); err != nil {
+		return err
+	}
+
+	if err := fn(); err != nil {
+		s.dirty = false
+		if loadErr := s.Load(); loadErr != nil {
+			return errors.Join(err, loadErr)
+		}
+		return err
+	}
+
+	if !s.dirty {
+		return nil
+	}
+	s.dirty = false
+	return s.save()
+}
+
+// AddTopic starts tracking a topic. heading addresses a section within
+// file and is empty for whole-file topics.
+func (s *JSONStorage) AddTopic(title, file, heading string, tags []string) (*Topic, error) {
+	id := generateID(file, title)
+
+	for _, t := range s.data.Topics {
+		if t.ID == id {
+			return &t, nil // Already exists
+		}
+	}
+
+	topic := Topic{
+		ID:      id,
+		Title:   title,
+		File:    file,
+		Heading: heading,
+		Tags:    tags,
+		Card:    fsrs.NewCard(),
+		Created: time.Now(),
+	}
+
+	s.data.Topics = append(s.data.Topics, topic)
+	s.dirty = true
+	return &topic, nil
+}
+
+func (s *JSONStorage) GetTopic(id string) *Topic {
+	for i := range s.data.Topics {
+		if s.data.Topics[i].ID == id {
+			return &s.data.Topics[i]
+		}
+	}
+	return nil
+}
+
+func (s *JSONStorage) GetTopicByTitle(title string) *Topic {
+	for i := range s.data.Topics {
+		if s.data.Topics[i].Title == title {
+			return &s.data.Topics[i]
+		}
+	}
+	return nil
+}
+
+// GetTopicByUID finds the topic for a frontmatter uid and section heading.
+func (s *JSONStorage) GetTopicByUID(uid, heading string) *Topic {
+	if uid == "" {
+		return nil
+	}
+	for i := range s.data.Topics {
+		if s.data.Topics[i].UID == uid && s.data.Topics[i].Heading == heading {
+			return &s.data.Topics[i]
+		}
+	}
+	return nil
+}
+
+func (s *JSONStorage) GetAllTopics() []Topic {
+	return s.data.Topics
+}
+
+func (s *JSONStorage) GetDueTopics(until time.Time) []Topic {
+	var due []Topic
+	for _, t := range s.data.Topics {
+		if !t.IsActive() {
+			continue
+		}
+		if at := t.DueAt(); at.Before(until) || at.Equal(until) {
+			due = append(due, t)
+		}
+	}
+	return due
+}
+
+func (s *JSONStorage) GetTopicsByTag(tag string) []Topic {
+	var matched []Topic
+	for _, t := range s.data.Topics {
+		if slices.Contains(t.Tags, tag) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+func (s *JSONStorage) UpdateTopic(topic *Topic) error {
+	for i := range s.data.Topics {
+		if s.data.Topics[i].ID == topic.ID {
+			s.data.Topics[i] = *topic
+			s.dirty = true
+			return nil
+		}
+	}
+	return nil
+}
+
+// AddReview appends a review log; build it with NewReviewLog.
+func (s *JSONStorage) AddReview(review ReviewLog) error {
+	s.data.Reviews = append(s.data.Reviews, review)
+	s.dirty = true
+	return nil
+}
+
+func (s *JSONStorage) GetAllReviews() []ReviewLog {
+	return s.data.Reviews
+}
+
+// GetReviewHistory returns the reviews of a topic itself, not of its
+// flashcards.
+func (s *JSONStorage) GetReviewHistory(topicID string) []ReviewLog {
+	var history []ReviewLog
+	for _, r := range s.data.Reviews {
+		if r.TopicID == topicID && r.CardID == "" {
+			history = append(history, r)
+		}
+	}
+	return history
+}
+
+func (s *JSONStorage) GetAllTags() map[string]int {
+	tags := make(map[string]int)
+	for _, t := range s.data.Topics {
+		for _, tag := range t.Tags {
+			tags[tag]++
+		}
+	}
+	return tags
+}
+
+// RelinkTopic moves an orphaned topic onto the location of another tracked
+// topic, which is removed. The orphan keeps its schedule and history while
+// taking over the target's title, file, heading, tags and identity fields.
+func (s *JSONStorage) RelinkTopic(orphanID, targetID string) (*Topic, error) {
+	target := s.GetTopic(targetID)
+	if target == nil {
+		return nil, fmt.Errorf("topic not found: %s", targetID)
+	}
+	t := *target
+
+	if err := s.RemoveTopic(targetID, false); err != nil {
+		return nil, err
+	}
+
+	topic, err := s.MoveTopic(orphanID, t.Title, t.File, t.Heading)
+	if err != nil {
+		return nil, err
+	}
+	topic.Tags = t.Tags
+	topic.UID = t.UID
+	topic.Fingerprint = t.Fingerprint
+	topic.Status = StatusActive
+
+	s.dirty = true
+	return topic, nil
+}
+
+// MoveTopic gives a topic a new title and location. Its ID is recomputed
+// and every review log and flashcard referring to it is rewritten, so the
+// schedule and history carry over.
+func (s *JSONStorage) MoveTopic(id, title, file, heading string) (*Topic, error) {
+	topic := s.GetTopic(id)
+	if topic == nil {
+		return nil, fmt.Errorf("topic not found: %s", id)
+	}
+
+	newID := generateID(file, title)
+	if newID != id && s.GetTopic(newID) != nil {
+		return nil, fmt.Errorf("topic already exists: %s", title)
+	}
+
+	topic.ID = newID
+	topic.Title = title
+	topic.File = file
+	topic.Heading = heading
+
+	cardIDs := make(map[string]string)
+	for i := range s.data.Flashcards {
+		c := &s.data.Flashcards[i]
+		if c.TopicID != id {
+			continue
+		}
+		c.TopicID = newID
+		newCardID := flashcardID(newID, c.Kind, c.Prompt)
+		cardIDs[c.ID] = newCardID
+		c.ID = newCardID
+	}
+
+	for i := range s.data.Reviews {
+		r := &s.data.Reviews[i]
+		if r.TopicID != id {
+			continue
+		}
+		r.TopicID = newID
+		if r.CardID != "" {
+			if newCardID, ok := cardIDs[r.CardID]; ok {
+				r.CardID = newCardID
+			}
+		}
+	}
+
+	s.dirty = true
+	return topic, nil
+}
+
+// RemoveTopic stops tracking a topic and its flashcards. Its review logs
+// are deleted too unless keepReviews is set, in which case they stay in
+// the file (e.g. as optimizer training data) without a topic.
+func (s *JSONStorage) RemoveTopic(id string, keepReviews bool) error {
+	for i, t := range s.data.Topics {
+		if t.ID == id {
+			s.data.Topics = append(s.data.Topics[:i], s.data.Topics[i+1:]...)
+			s.data.Flashcards = slices.DeleteFunc(s.data.Flashcards, func(c Flashcard) bool {
+				return c.TopicID == id
+			})
+			if !keepReviews {
+				s.data.Reviews = slices.DeleteFunc(s.data.Reviews, func(r ReviewLog) bool {
+					return r.TopicID == id
+				})
+			}
+			s.dirty = true
+			return nil
+		}
+	}
+	return nil
+}
+
+// GetLastReview returns the most recent review log of a topic or any of
+// its flashcards, or of any topic if topicID is empty.
+func (s *JSONStorage) GetLastReview(topicID string) *ReviewLog {
+	i := latestReview(s.data.Reviews, func(r ReviewLog) bool {
+		return topicID == "" || r.TopicID == topicID
+	})
+	if i < 0 {
+		return nil
+	}
+	r := s.data.Reviews[i]
+	return &r
+}
+
+// RemoveLastReview deletes the most recent review log of a topic, or of
+// one of its flashcards if cardID is set.
+func (s *JSONStorage) RemoveLastReview(topicID, cardID string) error {
+	i := latestReview(s.data.Reviews, func(r ReviewLog) bool {
+		return r.TopicID == topicID && r.CardID == cardID
+	})
+	if i < 0 {
+		return nil
+	}
+	s.data.Reviews = slices.Delete(s.data.Reviews, i, i+1)
+	s.dirty = true
+	return nil
+}
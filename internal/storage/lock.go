@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockTimeout = 10 * time.Second
+	lockPoll    = 50 * time.Millisecond
+)
+
+// lockFile takes an advisory exclusive lock on path, waiting up to
+// lockTimeout for other recall processes to release it. The returned
+// function releases the lock.
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%s is locked by another recall process", path)
+		}
+		time.Sleep(lockPoll)
+	}
+
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
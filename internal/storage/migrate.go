@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"sort"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+)
+
+// migrateData upgrades data written by older versions to DataVersion and
+// reports whether anything changed.
+//
+// Version 1 added kinds, states, intervals and retrievability to review
+// logs. They are filled in by replaying each card's history with the
+// default FSRS parameters, so intervals and retrievability of old logs are
+// estimates; undo snapshots are only kept where they already existed.
+func migrateData(d *Data) bool {
+	if d.Version >= DataVersion {
+		return false
+	}
+
+	groups := make(map[string][]int)
+	for i, r := range d.Reviews {
+		key := r.TopicID + ":" + r.CardID
+		groups[key] = append(groups[key], i)
+	}
+
+	scheduler := fsrs.NewScheduler()
+	for _, indexes := range groups {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			return d.Reviews[indexes[a]].ReviewedAt.Before(d.Reviews[indexes[b]].ReviewedAt)
+		})
+
+		card := fsrs.NewCard()
+		for _, i := range indexes {
+			r := &d.Reviews[i]
+			before := card
+			if r.Before != nil {
+				before = *r.Before
+			}
+			after := scheduler.Review(before, r.Rating, r.ReviewedAt)
+
+			if r.Kind == "" {
+				rich := NewReviewLog(fsrs.Clock{}, r.TopicID, r.CardID, r.Rating, before, after, 0)
+				rich.ReviewedAt = r.ReviewedAt
+				rich.Timezone = "" // Unknown
+				rich.Before = r.Before
+				*r = rich
+			}
+			card = after
+		}
+	}
+
+	d.Version = DataVersion
+	return true
+}
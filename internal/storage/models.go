@@ -6,32 +6,187 @@ import (
 	"github.com/amiraminb/recall/internal/fsrs"
 )
 
+// Topic statuses. The zero value is an active topic.
+const (
+	StatusActive    = ""
+	StatusArchived  = "archived"  // File is gone; kept for its review history
+	StatusSuspended = "suspended" // Not reviewed until unsuspended
+)
+
 type Topic struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	File    string `json:"file"`
+	Heading string `json:"heading,omitempty"` // Section key within File (see parser.Section), empty for the whole file
+	UID     string `json:"uid,omitempty"`     // uid from the file's frontmatter
+	Status  string `json:"status,omitempty"`
+
+	// NoteSuspended is set when the suspension came from "review: suspended"
+	// in the frontmatter, so scan can lift it when the frontmatter changes.
+	NoteSuspended bool `json:"note_suspended,omitempty"`
+
+	// BuriedUntil hides an otherwise active topic until this time.
+	BuriedUntil time.Time `json:"buried_until,omitzero"`
+	Tags        []string  `json:"tags"`
+	Card        fsrs.Card `json:"card"`
+	Created     time.Time `json:"created"`
+
+	// Fingerprint is a SimHash of the topic's content, used to recognize
+	// the topic after its file is renamed.
+	Fingerprint uint64 `json:"fingerprint,omitempty"`
+}
+
+// IsActive reports whether the topic is scheduled for review, i.e. it is
+// neither archived nor suspended. Buried topics are still active.
+func (t Topic) IsActive() bool {
+	return t.Status == StatusActive
+}
+
+// IsBuried reports whether the topic is buried at the given time.
+func (t Topic) IsBuried(at time.Time) bool {
+	return t.BuriedUntil.After(at)
+}
+
+// DueAt is when the topic is next shown: its card's due date, pushed back
+// to the end of any bury.
+func (t Topic) DueAt() time.Time {
+	if t.BuriedUntil.After(t.Card.Due) {
+		return t.BuriedUntil
+	}
+	return t.Card.Due
+}
+
+// Flashcard is a question/answer card extracted from a topic's notes,
+// scheduled independently of the topic itself.
+type Flashcard struct {
 	ID      string    `json:"id"`
-	Title   string    `json:"title"`
-	File    string    `json:"file"`
-	Tags    []string  `json:"tags"`
+	TopicID string    `json:"topic_id"`
+	Kind    string    `json:"kind"`
+	Prompt  string    `json:"prompt"`
+	Answer  string    `json:"answer"`
 	Card    fsrs.Card `json:"card"`
 	Created time.Time `json:"created"`
 }
 
+// Review log kinds.
+const (
+	KindRead       = "read"       // First read of a topic, or first review of a flashcard
+	KindReview     = "review"     // Review of a card in learning or review
+	KindRelearn    = "relearn"    // Review of a card relearning after a lapse
+	KindReschedule = "reschedule" // Schedule changed without a rating
+)
+
 // ReviewLog represents a single review event
 type ReviewLog struct {
 	TopicID    string      `json:"topic_id"`
+	CardID     string      `json:"card_id,omitempty"` // Set for flashcard reviews
 	ReviewedAt time.Time   `json:"reviewed_at"`
+	Timezone   string      `json:"timezone,omitempty"` // Zone the review's day was counted in
 	Rating     fsrs.Rating `json:"rating"`
+	Kind       string      `json:"kind"`
+
+	StateBefore fsrs.State `json:"state_before"`
+	StateAfter  fsrs.State `json:"state_after"`
+
+	// ElapsedDays is the time since the previous review; ScheduledDays is
+	// the interval this review set, both in scheduling days.
+	ElapsedDays   float64 `json:"elapsed_days"`
+	ScheduledDays int     `json:"scheduled_days"`
+
+	// Retrievability is the predicted chance of recall at review time.
+	Retrievability float64 `json:"retrievability"`
+
+	// DurationMS is the time from showing the prompt to the rating, in
+	// milliseconds; 0 if unknown.
+	DurationMS int64 `json:"duration_ms,omitempty"`
+
+	// Before is the card as it was before this review, so the review can
+	// be undone. Logs written by older versions do not have it.
+	Before *fsrs.Card `json:"before,omitempty"`
 }
 
+// NewReviewLog describes a review that changed a card from before to
+// after, with days counted by clock. It is dated at after.LastReview.
+func NewReviewLog(clock fsrs.Clock, topicID, cardID string, rating fsrs.Rating, before, after fsrs.Card, duration time.Duration) ReviewLog {
+	at := clock.In(after.LastReview)
+	r := ReviewLog{
+		TopicID:        topicID,
+		CardID:         cardID,
+		ReviewedAt:     at,
+		Timezone:       clock.Zone(),
+		Rating:         rating,
+		Kind:           reviewKind(before.State),
+		StateBefore:    before.State,
+		StateAfter:     after.State,
+		ScheduledDays:  clock.Days(at, after.Due),
+		Retrievability: fsrs.Retrievability(before, at, clock),
+		DurationMS:     duration.Milliseconds(),
+		Before:         &before,
+	}
+	if !before.LastReview.IsZero() {
+		r.ElapsedDays = float64(max(clock.Days(before.LastReview, at), 0))
+	}
+	return r
+}
+
+// NewRescheduleLog records a schedule change from before to after that
+// was not caused by a rating, such as 'recall reschedule'.
+func NewRescheduleLog(clock fsrs.Clock, topicID, cardID string, before, after fsrs.Card, at time.Time) ReviewLog {
+	at = clock.In(at)
+	return ReviewLog{
+		TopicID:        topicID,
+		CardID:         cardID,
+		ReviewedAt:     at,
+		Timezone:       clock.Zone(),
+		Kind:           KindReschedule,
+		StateBefore:    before.State,
+		StateAfter:     after.State,
+		ScheduledDays:  clock.Days(at, after.Due),
+		Retrievability: fsrs.Retrievability(before, at, clock),
+		Before:         &before,
+	}
+}
+
+// latestReview returns the index of the review that match accepts with
+// the latest ReviewedAt, or -1. Imported logs can be added after newer
+// ones, so insertion order is only used to break ties.
+func latestReview(reviews []ReviewLog, match func(ReviewLog) bool) int {
+	latest := -1
+	for i, r := range reviews {
+		if match(r) && (latest < 0 || !r.ReviewedAt.Before(reviews[latest].ReviewedAt)) {
+			latest = i
+		}
+	}
+	return latest
+}
+
+func reviewKind(before fsrs.State) string {
+	switch before {
+	case fsrs.New:
+		return KindRead
+	case fsrs.Relearn:
+		return KindRelearn
+	}
+	return KindReview
+}
+
+// DataVersion is the current layout of Data; see migrateData.
+const DataVersion = 1
+
 // Data is the root structure for the JSON storage file
 type Data struct {
-	Topics  []Topic     `json:"topics"`
-	Reviews []ReviewLog `json:"reviews"`
+	Version    int         `json:"version"`
+	Topics     []Topic     `json:"topics"`
+	Flashcards []Flashcard `json:"flashcards"`
+	Reviews    []ReviewLog `json:"reviews"`
 }
 
 // NewData creates an empty data structure
 func NewData() *Data {
 	return &Data{
-		Topics:  []Topic{},
-		Reviews: []ReviewLog{},
+		Version:    DataVersion,
+		Topics:     []Topic{},
+		Flashcards: []Flashcard{},
+		Reviews:    []ReviewLog{},
 	}
 }
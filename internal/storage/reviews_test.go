@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLastReviewByTime(t *testing.T) {
+	for _, backend := range []string{BackendJSON, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			store, err := Open(t.TempDir(), backend)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+			latest := ReviewLog{TopicID: "a", ReviewedAt: now, Kind: KindReview}
+			// Imported reviews are added after newer ones.
+			imported := []ReviewLog{
+				{TopicID: "a", ReviewedAt: now.AddDate(-1, 0, 0), Kind: KindReview},
+				{TopicID: "b", ReviewedAt: now.AddDate(0, 0, -1), Kind: KindReview},
+				{TopicID: "a", CardID: "c", ReviewedAt: now.Add(-time.Hour), Kind: KindReview},
+			}
+			err = store.Update(func() error {
+				for _, r := range append([]ReviewLog{latest}, imported...) {
+					if err := store.AddReview(r); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, topicID := range []string{"", "a"} {
+				if got := store.GetLastReview(topicID); got == nil || !got.ReviewedAt.Equal(now) {
+					t.Errorf("GetLastReview(%q) = %+v, want the review at %v", topicID, got, now)
+				}
+			}
+
+			if err := store.Update(func() error { return store.RemoveLastReview("a", "") }); err != nil {
+				t.Fatal(err)
+			}
+			history := store.GetReviewHistory("a")
+			if len(history) != 1 || !history[0].ReviewedAt.Equal(imported[0].ReviewedAt) {
+				t.Errorf("after removing, history = %+v, want only the imported review", history)
+			}
+			if got := store.GetLastReview("a"); got == nil || got.CardID != "c" {
+				t.Errorf("GetLastReview after removing = %+v, want the flashcard review", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,621 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/amiraminb/recall/internal/fsrs"
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStorage keeps data in an SQLite database. Each row stores the full
+// record as JSON next to the columns that are indexed, so model changes do
+// not need schema migrations.
+//
+// Getters do not return errors; a failed query is remembered and returned
+// by the next Update (or Close).
+type SQLiteStorage struct {
+	db  *sql.DB
+	tx  *sql.Tx
+	err error
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS topics (
+	seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+	id      TEXT NOT NULL UNIQUE,
+	title   TEXT NOT NULL,
+	uid     TEXT NOT NULL DEFAULT '',
+	heading TEXT NOT NULL DEFAULT '',
+	status  TEXT NOT NULL DEFAULT '',
+	due     INTEGER NOT NULL,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS topics_title ON topics(title);
+CREATE INDEX IF NOT EXISTS topics_uid ON topics(uid, heading);
+CREATE INDEX IF NOT EXISTS topics_due ON topics(status, due);
+
+CREATE TABLE IF NOT EXISTS flashcards (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	id       TEXT NOT NULL UNIQUE,
+	topic_id TEXT NOT NULL,
+	due      INTEGER NOT NULL,
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS flashcards_topic ON flashcards(topic_id);
+CREATE INDEX IF NOT EXISTS flashcards_due ON flashcards(due);
+
+CREATE TABLE IF NOT EXISTS reviews (
+	seq      INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic_id TEXT NOT NULL,
+	card_id  TEXT NOT NULL DEFAULT '',
+	data     TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS reviews_topic ON reviews(topic_id, card_id);
+`
+
+func NewSQLiteStorage(wikiPath string) (*SQLiteStorage, error) {
+	srsDir := filepath.Join(wikiPath, ".srs")
+	if err := os.MkdirAll(srsDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	// Immediate transactions take the write lock up front, so concurrent
+	// recall processes queue up instead of failing mid-update.
+	dsn := "file:" + filepath.Join(srsDir, "reviews.db") +
+		"?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLiteStorage{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// migrate upgrades a database written by an older version, tracked in
+// SQLite's user_version; see migrateData.
+func (s *SQLiteStorage) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+	if version >= DataVersion {
+		return nil
+	}
+
+	return s.Update(func() error {
+		data, err := s.Export()
+		if err != nil {
+			return err
+		}
+		data.Version = version
+		if migrateData(data) {
+			if err := s.Import(data); err != nil {
+				return err
+			}
+		}
+		return s.exec(fmt.Sprintf(`PRAGMA user_version = %d`, DataVersion))
+	})
+}
+
+func (s *SQLiteStorage) Close() error {
+	return errors.Join(s.err, s.db.Close())
+}
+
+// Update runs fn inside a transaction, committing if fn succeeds.
+func (s *SQLiteStorage) Update(fn func() error) error {
+	if s.tx != nil {
+		return errors.New("nested storage update")
+	}
+	if err := s.err; err != nil {
+		s.err = nil
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	s.tx = tx
+
+	err = fn()
+	if err == nil {
+		err = s.err
+	}
+	s.tx = nil
+	s.err = nil
+
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func (s *SQLiteStorage) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *SQLiteStorage) fail(err error) {
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *SQLiteStorage) exec(query string, args ...any) error {
+	_, err := s.q().Exec(query, args...)
+	return err
+}
+
+// queryJSON decodes the JSON data column of every row into a T.
+func queryJSON[T any](s *SQLiteStorage, query string, args ...any) []T {
+	rows, err := s.q().Query(query, args...)
+	if err != nil {
+		s.fail(err)
+		return nil
+	}
+	defer rows.Close()
+
+	var result []T
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			s.fail(err)
+			return nil
+		}
+		var v T
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			s.fail(err)
+			return nil
+		}
+		result = append(result, v)
+	}
+	s.fail(rows.Err())
+	return result
+}
+
+func queryOne[T any](s *SQLiteStorage, query string, args ...any) *T {
+	rows := queryJSON[T](s, query+" LIMIT 1", args...)
+	if len(rows) == 0 {
+		return nil
+	}
+	return &rows[0]
+}
+
+func encode(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// unixDue is the indexed due column: whole seconds, rounded up so that
+// "due <= until" never misses a row. Exact comparisons happen in Go.
+func unixDue(t time.Time) int64 {
+	if t.Nanosecond() > 0 {
+		return t.Unix() + 1
+	}
+	return t.Unix()
+}
+
+func (s *SQLiteStorage) putTopic(t *Topic) error {
+	data, err := encode(t)
+	if err != nil {
+		return err
+	}
+	return s.exec(`INSERT INTO topics (id, title, uid, heading, status, due, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = excluded.title, uid = excluded.uid,
+			heading = excluded.heading, status = excluded.status,
+			due = excluded.due, data = excluded.data`,
+		t.ID, t.Title, t.UID, t.Heading, t.Status, unixDue(t.DueAt()), data)
+}
+
+func (s *SQLiteStorage) putFlashcard(c *Flashcard) error {
+	data, err := encode(c)
+	if err != nil {
+		return err
+	}
+	return s.exec(`INSERT INTO flashcards (id, topic_id, due, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET topic_id = excluded.topic_id,
+			due = excluded.due, data = excluded.data`,
+		c.ID, c.TopicID, unixDue(c.Card.Due), data)
+}
+
+func (s *SQLiteStorage) addReviewLog(r ReviewLog) error {
+	data, err := encode(r)
+	if err != nil {
+		return err
+	}
+	return s.exec(`INSERT INTO reviews (topic_id, card_id, data) VALUES (?, ?, ?)`,
+		r.TopicID, r.CardID, data)
+}
+
+func (s *SQLiteStorage) AddTopic(title, file, heading string, tags []string) (*Topic, error) {
+	id := generateID(file, title)
+	if t := s.GetTopic(id); t != nil {
+		return t, nil // Already exists
+	}
+
+	topic := Topic{
+		ID:      id,
+		Title:   title,
+		File:    file,
+		Heading: heading,
+		Tags:    tags,
+		Card:    fsrs.NewCard(),
+		Created: time.Now(),
+	}
+	return &topic, s.putTopic(&topic)
+}
+
+func (s *SQLiteStorage) GetTopic(id string) *Topic {
+	return queryOne[Topic](s, `SELECT data FROM topics WHERE id = ?`, id)
+}
+
+func (s *SQLiteStorage) GetTopicByTitle(title string) *Topic {
+	return queryOne[Topic](s, `SELECT data FROM topics WHERE title = ? ORDER BY seq`, title)
+}
+
+func (s *SQLiteStorage) GetTopicByUID(uid, heading string) *Topic {
+	if uid == "" {
+		return nil
+	}
+	return queryOne[Topic](s, `SELECT data FROM topics WHERE uid = ? AND heading = ? ORDER BY seq`, uid, heading)
+}
+
+func (s *SQLiteStorage) GetAllTopics() []Topic {
+	return queryJSON[Topic](s, `SELECT data FROM topics ORDER BY seq`)
+}
+
+func (s *SQLiteStorage) GetDueTopics(until time.Time) []Topic {
+	topics := queryJSON[Topic](s, `SELECT data FROM topics WHERE status = ? AND due <= ? ORDER BY seq`,
+		StatusActive, unixDue(until)+1)
+	return slices.DeleteFunc(topics, func(t Topic) bool {
+		return t.DueAt().After(until)
+	})
+}
+
+func (s *SQLiteStorage) GetTopicsByTag(tag string) []Topic {
+	var matched []Topic
+	for _, t := range s.GetAllTopics() {
+		if slices.Contains(t.Tags, tag) {
+			matched = append(matched, t)
+		}
+	}
+	return matched
+}
+
+func (s *SQLiteStorage) UpdateTopic(topic *Topic) error {
+	if s.GetTopic(topic.ID) == nil {
+		return nil
+	}
+	return s.putTopic(topic)
+}
+
+func (s *SQLiteStorage) MoveTopic(id, title, file, heading string) (*Topic, error) {
+	topic := s.GetTopic(id)
+	if topic == nil {
+		return nil, fmt.Errorf("topic not found: %s", id)
+	}
+
+	newID := generateID(file, title)
+	if newID != id && s.GetTopic(newID) != nil {
+		return nil, fmt.Errorf("topic already exists: %s", title)
+	}
+
+	topic.ID = newID
+	topic.Title = title
+	topic.File = file
+	topic.Heading = heading
+	if err := s.exec(`UPDATE topics SET id = ? WHERE id = ?`, newID, id); err != nil {
+		return nil, err
+	}
+	if err := s.putTopic(topic); err != nil {
+		return nil, err
+	}
+
+	cardIDs := make(map[string]string)
+	for _, c := range s.GetFlashcards(id) {
+		oldID := c.ID
+		c.TopicID = newID
+		c.ID = flashcardID(newID, c.Kind, c.Prompt)
+		cardIDs[oldID] = c.ID
+		if err := s.exec(`UPDATE flashcards SET id = ? WHERE id = ?`, c.ID, oldID); err != nil {
+			return nil, err
+		}
+		if err := s.putFlashcard(&c); err != nil {
+			return nil, err
+		}
+	}
+
+	reviews, err := s.reviewRows(`WHERE topic_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reviews {
+		r.log.TopicID = newID
+		if newCardID, ok := cardIDs[r.log.CardID]; ok {
+			r.log.CardID = newCardID
+		}
+		data, err := encode(r.log)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.exec(`UPDATE reviews SET topic_id = ?, card_id = ?, data = ? WHERE seq = ?`,
+			r.log.TopicID, r.log.CardID, data, r.seq); err != nil {
+			return nil, err
+		}
+	}
+
+	return topic, nil
+}
+
+func (s *SQLiteStorage) RelinkTopic(orphanID, targetID string) (*Topic, error) {
+	target := s.GetTopic(targetID)
+	if target == nil {
+		return nil, fmt.Errorf("topic not found: %s", targetID)
+	}
+
+	if err := s.RemoveTopic(targetID, false); err != nil {
+		return nil, err
+	}
+
+	topic, err := s.MoveTopic(orphanID, target.Title, target.File, target.Heading)
+	if err != nil {
+		return nil, err
+	}
+	topic.Tags = target.Tags
+	topic.UID = target.UID
+	topic.Fingerprint = target.Fingerprint
+	topic.Status = StatusActive
+
+	return topic, s.putTopic(topic)
+}
+
+func (s *SQLiteStorage) RemoveTopic(id string, keepReviews bool) error {
+	if err := s.exec(`DELETE FROM topics WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := s.exec(`DELETE FROM flashcards WHERE topic_id = ?`, id); err != nil {
+		return err
+	}
+	if keepReviews {
+		return nil
+	}
+	return s.exec(`DELETE FROM reviews WHERE topic_id = ?`, id)
+}
+
+func (s *SQLiteStorage) GetAllTags() map[string]int {
+	tags := make(map[string]int)
+	for _, t := range s.GetAllTopics() {
+		for _, tag := range t.Tags {
+			tags[tag]++
+		}
+	}
+	return tags
+}
+
+func (s *SQLiteStorage) AddReview(review ReviewLog) error {
+	return s.addReviewLog(review)
+}
+
+func (s *SQLiteStorage) GetAllReviews() []ReviewLog {
+	return queryJSON[ReviewLog](s, `SELECT data FROM reviews ORDER BY seq`)
+}
+
+func (s *SQLiteStorage) GetReviewHistory(topicID string) []ReviewLog {
+	return queryJSON[ReviewLog](s, `SELECT data FROM reviews WHERE topic_id = ? AND card_id = '' ORDER BY seq`, topicID)
+}
+
+// GetLastReview picks by ReviewedAt, which is inside the JSON data, so
+// the candidates are compared in Go.
+func (s *SQLiteStorage) GetLastReview(topicID string) *ReviewLog {
+	var rows []reviewRow
+	var err error
+	if topicID == "" {
+		rows, err = s.reviewRows(``)
+	} else {
+		rows, err = s.reviewRows(`WHERE topic_id = ?`, topicID)
+	}
+	if err != nil {
+		s.fail(err)
+		return nil
+	}
+	if i := latestRow(rows); i >= 0 {
+		return &rows[i].log
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) RemoveLastReview(topicID, cardID string) error {
+	rows, err := s.reviewRows(`WHERE topic_id = ? AND card_id = ?`, topicID, cardID)
+	if err != nil {
+		return err
+	}
+	i := latestRow(rows)
+	if i < 0 {
+		return nil
+	}
+	return s.exec(`DELETE FROM reviews WHERE seq = ?`, rows[i].seq)
+}
+
+// reviewRow is a review log with its row number.
+type reviewRow struct {
+	seq int64
+	log ReviewLog
+}
+
+// reviewRows returns the review logs matching where, in insertion order.
+func (s *SQLiteStorage) reviewRows(where string, args ...any) ([]reviewRow, error) {
+	rows, err := s.q().Query(`SELECT seq, data FROM reviews `+where+` ORDER BY seq`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []reviewRow
+	for rows.Next() {
+		var r reviewRow
+		var raw string
+		if err := rows.Scan(&r.seq, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &r.log); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, rows.Err()
+}
+
+// latestRow is latestReview over review rows.
+func latestRow(rows []reviewRow) int {
+	logs := make([]ReviewLog, len(rows))
+	for i, r := range rows {
+		logs[i] = r.log
+	}
+	return latestReview(logs, func(ReviewLog) bool { return true })
+}
+
+func (s *SQLiteStorage) SyncFlashcards(topicID string, cards []Flashcard) (added, removed int, err error) {
+	existing := make(map[string]*Flashcard)
+	for _, c := range s.GetFlashcards(topicID) {
+		existing[c.ID] = &c
+	}
+
+	keep := make(map[string]bool)
+	for _, c := range cards {
+		id := flashcardID(topicID, c.Kind, c.Prompt)
+		if keep[id] {
+			continue
+		}
+		keep[id] = true
+
+		if fc := existing[id]; fc != nil {
+			if fc.Answer != c.Answer {
+				fc.Answer = c.Answer
+				if err := s.putFlashcard(fc); err != nil {
+					return added, removed, err
+				}
+			}
+			continue
+		}
+
+		fc := Flashcard{
+			ID:      id,
+			TopicID: topicID,
+			Kind:    c.Kind,
+			Prompt:  c.Prompt,
+			Answer:  c.Answer,
+			Card:    fsrs.NewCard(),
+			Created: time.Now(),
+		}
+		if err := s.putFlashcard(&fc); err != nil {
+			return added, removed, err
+		}
+		added++
+	}
+
+	for id := range existing {
+		if keep[id] {
+			continue
+		}
+		if err := s.exec(`DELETE FROM flashcards WHERE id = ?`, id); err != nil {
+			return added, removed, err
+		}
+		removed++
+	}
+
+	return added, removed, nil
+}
+
+func (s *SQLiteStorage) GetFlashcard(id string) *Flashcard {
+	return queryOne[Flashcard](s, `SELECT data FROM flashcards WHERE id = ?`, id)
+}
+
+func (s *SQLiteStorage) GetFlashcards(topicID string) []Flashcard {
+	return queryJSON[Flashcard](s, `SELECT data FROM flashcards WHERE topic_id = ? ORDER BY seq`, topicID)
+}
+
+func (s *SQLiteStorage) GetDueFlashcards(until time.Time) []Flashcard {
+	cards := queryJSON[Flashcard](s, `SELECT f.data FROM flashcards f
+		JOIN topics t ON t.id = f.topic_id
+		WHERE t.status = ? AND f.due <= ? ORDER BY f.seq`,
+		StatusActive, unixDue(until)+1)
+
+	topics := make(map[string]*Topic)
+	return slices.DeleteFunc(cards, func(c Flashcard) bool {
+		t, ok := topics[c.TopicID]
+		if !ok {
+			t = s.GetTopic(c.TopicID)
+			topics[c.TopicID] = t
+		}
+		return t == nil || t.IsBuried(until) || c.Card.Due.After(until)
+	})
+}
+
+func (s *SQLiteStorage) UpdateFlashcard(card *Flashcard) error {
+	if s.GetFlashcard(card.ID) == nil {
+		return nil
+	}
+	return s.putFlashcard(card)
+}
+
+func (s *SQLiteStorage) Export() (*Data, error) {
+	data := &Data{
+		Topics:     s.GetAllTopics(),
+		Flashcards: queryJSON[Flashcard](s, `SELECT data FROM flashcards ORDER BY seq`),
+		Reviews:    s.GetAllReviews(),
+	}
+	if err := s.err; err != nil {
+		s.err = nil
+		return nil, err
+	}
+	return data, nil
+}
+
+func (s *SQLiteStorage) Import(data *Data) error {
+	for _, table := range []string{"topics", "flashcards", "reviews"} {
+		if err := s.exec(`DELETE FROM ` + table); err != nil {
+			return err
+		}
+	}
+
+	for i := range data.Topics {
+		if err := s.putTopic(&data.Topics[i]); err != nil {
+			return err
+		}
+	}
+	for i := range data.Flashcards {
+		if err := s.putFlashcard(&data.Flashcards[i]); err != nil {
+			return err
+		}
+	}
+	for _, r := range data.Reviews {
+		if err := s.addReviewLog(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
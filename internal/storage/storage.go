@@ -3,162 +3,67 @@ package storage
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
-	"os"
-	"path/filepath"
-	"slices"
+	"fmt"
 	"time"
-
-	"github.com/amiraminb/recall/internal/fsrs"
 )
 
-// Storage handles reading/writing the JSON data file.
-type Storage struct {
-	path string
-	data *Data
-}
-
-func NewStorage(wikiPath string) (*Storage, error) {
-	srsDir := filepath.Join(wikiPath, ".srs")
-	if err := os.MkdirAll(srsDir, 0o755); err != nil {
-		return nil, err
-	}
-
-	s := &Storage{
-		path: filepath.Join(srsDir, "reviews.json"),
-	}
-
-	if err := s.Load(); err != nil {
-		return nil, err
-	}
-
-	return s, nil
-}
-
-func (s *Storage) Load() error {
-	data, err := os.ReadFile(s.path)
-	if os.IsNotExist(err) {
-		s.data = NewData()
-		return nil
-	}
-	if err != nil {
-		return err
-	}
-
-	s.data = &Data{}
-	return json.Unmarshal(data, s.data)
-}
-
-func (s *Storage) Save() error {
-	data, err := json.MarshalIndent(s.data, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(s.path, data, 0o644)
-}
-
-func (s *Storage) AddTopic(title, file string, tags []string) (*Topic, error) {
-	id := generateID(file, title)
-
-	for _, t := range s.data.Topics {
-		if t.ID == id {
-			return &t, nil // Already exists
-		}
-	}
-
-	topic := Topic{
-		ID:      id,
-		Title:   title,
-		File:    file,
-		Tags:    tags,
-		Card:    fsrs.NewCard(),
-		Created: time.Now(),
-	}
-
-	s.data.Topics = append(s.data.Topics, topic)
-	return &topic, s.Save()
-}
-
-func (s *Storage) GetTopic(id string) *Topic {
-	for i := range s.data.Topics {
-		if s.data.Topics[i].ID == id {
-			return &s.data.Topics[i]
-		}
-	}
-	return nil
-}
-
-func (s *Storage) GetTopicByTitle(title string) *Topic {
-	for i := range s.data.Topics {
-		if s.data.Topics[i].Title == title {
-			return &s.data.Topics[i]
-		}
-	}
-	return nil
-}
-
-func (s *Storage) GetAllTopics() []Topic {
-	return s.data.Topics
-}
-
-func (s *Storage) GetDueTopics(until time.Time) []Topic {
-	var due []Topic
-	for _, t := range s.data.Topics {
-		if t.Card.Due.Before(until) || t.Card.Due.Equal(until) {
-			due = append(due, t)
-		}
-	}
-	return due
-}
-
-func (s *Storage) GetTopicsByTag(tag string) []Topic {
-	var matched []Topic
-	for _, t := range s.data.Topics {
-		if slices.Contains(t.Tags, tag) {
-			matched = append(matched, t)
-		}
-	}
-	return matched
-}
-
-func (s *Storage) UpdateTopic(topic *Topic) error {
-	for i := range s.data.Topics {
-		if s.data.Topics[i].ID == topic.ID {
-			s.data.Topics[i] = *topic
-			return s.Save()
-		}
-	}
-	return nil
-}
-
-func (s *Storage) AddReview(topicID string, rating fsrs.Rating) error {
-	review := ReviewLog{
-		TopicID:    topicID,
-		ReviewedAt: time.Now(),
-		Rating:     rating,
-	}
-	s.data.Reviews = append(s.data.Reviews, review)
-	return s.Save()
-}
-
-func (s *Storage) GetReviewHistory(topicID string) []ReviewLog {
-	var history []ReviewLog
-	for _, r := range s.data.Reviews {
-		if r.TopicID == topicID {
-			history = append(history, r)
-		}
-	}
-	return history
-}
+// Storage backends.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
 
-func (s *Storage) GetAllTags() map[string]int {
-	tags := make(map[string]int)
-	for _, t := range s.data.Topics {
-		for _, tag := range t.Tags {
-			tags[tag]++
-		}
-	}
-	return tags
+// Storage stores topics, flashcards and review logs.
+//
+// Changes must be made inside Update, which serializes writers across
+// recall processes and commits everything fn did at once. Getters return
+// copies or pointers that are only valid until the next Update; pass
+// modified topics back through UpdateTopic.
+type Storage interface {
+	Update(fn func() error) error
+	Close() error
+
+	AddTopic(title, file, heading string, tags []string) (*Topic, error)
+	GetTopic(id string) *Topic
+	GetTopicByTitle(title string) *Topic
+	GetTopicByUID(uid, heading string) *Topic
+	GetAllTopics() []Topic
+	GetDueTopics(until time.Time) []Topic
+	GetTopicsByTag(tag string) []Topic
+	UpdateTopic(topic *Topic) error
+	MoveTopic(id, title, file, heading string) (*Topic, error)
+	RelinkTopic(orphanID, targetID string) (*Topic, error)
+	RemoveTopic(id string, keepReviews bool) error
+	GetAllTags() map[string]int
+
+	AddReview(review ReviewLog) error
+	GetAllReviews() []ReviewLog
+	GetReviewHistory(topicID string) []ReviewLog
+	GetLastReview(topicID string) *ReviewLog
+	RemoveLastReview(topicID, cardID string) error
+
+	SyncFlashcards(topicID string, cards []Flashcard) (added, removed int, err error)
+	GetFlashcard(id string) *Flashcard
+	GetFlashcards(topicID string) []Flashcard
+	GetDueFlashcards(until time.Time) []Flashcard
+	UpdateFlashcard(card *Flashcard) error
+
+	// Export returns all data; Import replaces all data and must be
+	// called inside Update. Together they move data between backends.
+	Export() (*Data, error)
+	Import(data *Data) error
+}
+
+// Open opens the wiki's storage using the named backend; an empty name
+// means JSON.
+func Open(wikiPath, backend string) (Storage, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewJSONStorage(wikiPath)
+	case BackendSQLite:
+		return NewSQLiteStorage(wikiPath)
+	}
+	return nil, fmt.Errorf("unknown storage backend: %s", backend)
 }
 
 func generateID(file, title string) string {
@@ -166,12 +71,6 @@ func generateID(file, title string) string {
 	return hex.EncodeToString(hash[:8])
 }
 
-func (s *Storage) RemoveTopic(id string) error {
-	for i, t := range s.data.Topics {
-		if t.ID == id {
-			s.data.Topics = append(s.data.Topics[:i], s.data.Topics[i+1:]...)
-			return s.Save()
-		}
-	}
-	return nil
+func flashcardID(topicID, kind, prompt string) string {
+	return generateID(topicID, kind+":"+prompt)
 }